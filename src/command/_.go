@@ -20,8 +20,12 @@ The following commandline notations are supported:
 	  app -verbose run -dry
 	* arguments to the command
 	  app test network filesystem
+	* nested commands
+	  app remote add origin
 	* arguments to flags
 	  app print -device=spool0 -priority=4
+	* required arguments to flags
+	  app print -device spool0
 	* app run bash -- --login
 	  early parsing terminator
 
@@ -29,12 +33,49 @@ An example making use of all features:
 
 	app -verbose -nice=4 test ./src/test -fail -retry=2 "*.test"
 
+Parsers created with NewGNUParser accept the POSIX/GNU notation
+instead, i.e. long flags with two dashes and clustered single
+character flags:
+
+	app --verbose --nice 4 test -fj4 "*.test"
+
 Flags itself are optional, their arguments on the other hand can
 be marked as mandatory (e.g. for values without safe defaults like
 configuration files). Values for flags can also be read from the
 environment. If not empty is satisfies the presence requirement of
 a flag.
 
+Usually the last occurrence of a flag wins. Flags created with
+Flag.Strings, Flag.Ints or Flag.StringMap collect all occurrences
+instead and optionally split them (see Flag.Split); their environment
+variable is split at commas:
+
+	app -tag=a,b -tag c -label=env=prod
+
+Flag values can also be read from an INI or JSON file named by a flag
+(see Parser.ConfigFlag). The command-line takes precedence over the
+environment, which in turn takes precedence over the file:
+
+	jobs = 4
+
+	[test]
+	retry = 2
+
+Flags and commands can also be declared through struct tags (see
+Parser.Bind):
+
+	var options struct {
+		Verbose bool `desc:"Set the verbosity level"`
+		Test    struct {
+			Jobs int `flag:"jobs" value:"NUM" default:"1"`
+		} `command:"test" desc:"Run the test suite"`
+	}
+
+	app.Bind(&options)
+
+The origin of each value is tracked (see Parser.Provenance) and can be
+listed along with the values (see Parser.WriteEffectiveConfig).
+
 Below is an example which defines a single command, "test":
 
 	// APPRC=.apprc app -verbose -config test "*.test" -jobs=4 -- -v
@@ -106,5 +147,23 @@ For errors:
 
 	Flag processing can be terminated using --
 
+Instead of checking the triggered command after parsing, actions can
+be assigned to commands (see Command.Action) and the application (see
+Parser.Action). Parser.Run parses the arguments and runs the action of
+the triggered command. It also answers the help flag with the usage
+message. Common tasks like logging can be set up through hooks run
+before and after the action (see Parser.Before and Parser.After) or
+through middleware wrapping it (see Parser.Use).
+Parser.RunContext passes a context to the actions, which can be
+cancelled on interrupts (see Parser.CancelOnSignal). Parser.Main
+runs the application and terminates the process with exit code 0 on
+success, 2 on usage errors or a custom code (see ExitError).
+
+Completion scripts for bash, zsh and fish are written by
+WriteCompletion. Values only known at runtime (see Flag.Complete)
+are requested from the application through the hidden command
+"__complete", which Parser.Run answers by writing the candidates to
+stdout (see WriteCandidates).
+
 */
 package command
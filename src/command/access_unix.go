@@ -0,0 +1,12 @@
+//go:build !windows
+
+package command
+
+import (
+	"syscall"
+)
+
+// Check the permissions of the current user on the given path.
+func checkAccess(path string, mode uint32) error {
+	return syscall.Access(path, mode)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// Check the permissions on the given path. Windows has no notion
+// of access(2), so the permission bits reported by Stat are used.
+func checkAccess(path string, mode uint32) error {
+	info, err := os.Stat(path)
+
+	if nil != err {
+		return err
+	} else if os.FileMode(mode<<6)&info.Mode().Perm() == 0 {
+		return fmt.Errorf("permission denied: %s", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	bindPrefixSep  = "-"
+	bindOptional   = "optional"
+	bindRequired   = "true"
+	bindSliceSep   = ","
+	bindTagSep     = ","
+	bindIgnoreName = "-"
+)
+
+var (
+	commandType  = reflect.TypeOf((*Command)(nil))
+	durationType = reflect.TypeOf(time.Duration(0))
+	valueType    = reflect.TypeOf((*Value)(nil)).Elem()
+)
+
+// The registration methods shared by Parser and Command.
+type flagRegistry interface {
+	Flag(name string, description string) *Flag
+	Command(name string, description string) *Command
+}
+
+// A struct field of a basic type (see Bind).
+type fieldValue struct {
+	out reflect.Value
+}
+
+// A slice field (see Bind). Each occurrence of the flag appends its
+// elements (see Flag.Split); the first one replaces the default
+// elements, which are restored before each parse.
+type sliceFieldValue struct {
+	out      reflect.Value
+	split    *string
+	defaults reflect.Value
+	fresh    bool
+}
+
+// Register a flag for each exported field of the struct pointed to
+// by options. The flag name is taken from the "flag" tag or derived
+// from the field name ("MaxJobs" becomes "max-jobs"); "-" skips the
+// field. Further tags configure the flag:
+//
+//	desc      description
+//	short     single character name (see Flag.Short)
+//	env       environment variable (see Flag.EnvironmentValue)
+//	value     value name, optionally followed by ",optional"; the
+//	          value of non-boolean flags is required by default
+//	group     group in the usage message (see Flag.Group)
+//	required  "true" to mark the flag as required
+//	default   value assigned before parsing
+//	split     separator of the elements of slice fields (see Flag.Split)
+//
+// Supported field types are strings, booleans, integers, floats,
+// time.Duration, slices of these (repeatable flags) and types
+// implementing Value through their pointer. Nested structs become
+// flags prefixed with the name of the struct field, e.g. "db-host";
+// the fields of embedded structs are bound without prefix. A struct
+// field with a "command" tag becomes a sub-command with the fields of
+// the struct as its flags. Fields of type *Command receive the command
+// the struct is bound to. Bound to the parser or given a "command" tag
+// they receive a new sub-command named after the field (or the tag).
+func (p *Parser) Bind(options interface{}) error {
+	return bind(options, p, nil)
+}
+
+// Register flags and sub-commands for the fields of the struct
+// pointed to by options (see Parser.Bind).
+func (c *Command) Bind(options interface{}) error {
+	return bind(options, c, c)
+}
+
+func bind(options interface{}, owner flagRegistry, cmd *Command) error {
+	target := reflect.ValueOf(options)
+
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Unable to bind %T, expected a pointer to a struct", options)
+	}
+
+	return bindFields(target.Elem(), "", owner, cmd)
+}
+
+func bindFields(target reflect.Value, prefix string, owner flagRegistry, cmd *Command) error {
+	for index := 0; index < target.NumField(); index++ {
+		field := target.Type().Field(index)
+		out := target.Field(index)
+		name, tagged := field.Tag.Lookup("flag")
+
+		if field.Anonymous && false == tagged && isNestedStruct(out) {
+			// promoted fields, even of unexported types
+			if err := bindFields(out, prefix, owner, cmd); nil != err {
+				return err
+			}
+
+			continue
+		} else if len(field.PkgPath) > 0 || name == bindIgnoreName {
+			continue
+		} else if false == tagged {
+			name = fieldFlagName(field.Name)
+		}
+
+		if len(prefix) > 0 {
+			name = prefix + bindPrefixSep + name
+		}
+
+		if sub, ok := field.Tag.Lookup("command"); ok {
+			if err := bindCommand(out, field, sub, owner); nil != err {
+				return err
+			}
+		} else if field.Type == commandType && nil != cmd {
+			out.Set(reflect.ValueOf(cmd))
+		} else if field.Type == commandType {
+			out.Set(reflect.ValueOf(owner.Command(name, field.Tag.Get("desc"))))
+		} else if isNestedStruct(out) {
+			if err := bindFields(out, name, owner, cmd); nil != err {
+				return err
+			}
+		} else if err := bindFlag(out, field, name, owner); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bindCommand(out reflect.Value, field reflect.StructField, name string, owner flagRegistry) error {
+	if field.Type == commandType {
+		out.Set(reflect.ValueOf(owner.Command(name, field.Tag.Get("desc"))))
+
+		return nil
+	} else if out.Kind() == reflect.Ptr && out.Type().Elem().Kind() == reflect.Struct {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+
+		out = out.Elem()
+	}
+
+	if out.Kind() != reflect.Struct {
+		return fmt.Errorf("Unable to bind field %s of type %s as command", field.Name, field.Type)
+	}
+
+	sub := owner.Command(name, field.Tag.Get("desc"))
+
+	return bindFields(out, "", sub, sub)
+}
+
+func bindFlag(out reflect.Value, field reflect.StructField, name string, owner flagRegistry) error {
+	var value Value
+
+	sep := field.Tag.Get("split")
+
+	if out.Addr().Type().Implements(valueType) {
+		value = out.Addr().Interface().(Value)
+	} else if out.Kind() == reflect.Slice && bindableKind(out.Type().Elem()) {
+		value = &sliceFieldValue{out, &sep, reflect.Value{}, true}
+	} else if bindableKind(out.Type()) {
+		value = &fieldValue{out}
+	} else {
+		return fmt.Errorf("Unable to bind field %s of type %s", field.Name, field.Type)
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		if err := value.Set(def); nil != err {
+			return fmt.Errorf("Invalid default value of field %s: %v", field.Name, err)
+		}
+	}
+
+	flag := owner.Flag(name, field.Tag.Get("desc")).Split(sep)
+
+	if slice, ok := value.(*sliceFieldValue); ok {
+		slice.split = &flag.split
+		slice.defaults = copySlice(out)
+		slice.fresh = true
+	}
+
+	if short := []rune(field.Tag.Get("short")); len(short) == 1 {
+		flag.Short(short[0])
+	}
+
+	if env, ok := field.Tag.Lookup("env"); ok {
+		flag.EnvironmentValue(env)
+	}
+
+	if group, ok := field.Tag.Lookup("group"); ok {
+		flag.Group(group)
+	}
+
+	if false == isBoolFlag(value) {
+		parts := strings.SplitN(field.Tag.Get("value"), bindTagSep, 2)
+
+		if len(parts[0]) == 0 {
+			parts[0] = flagValueName
+		}
+
+		flag.Value(parts[0], len(parts) == 1 || parts[1] != bindOptional)
+	}
+
+	if field.Tag.Get("required") == bindRequired {
+		flag.Required()
+	}
+
+	flag.Var(value)
+
+	return nil
+}
+
+func (f *fieldValue) Set(value string) error {
+	return setField(f.out, value)
+}
+
+func (f *fieldValue) Get() interface{} {
+	return f.out.Interface()
+}
+
+func (f *fieldValue) String() string {
+	return fmt.Sprint(f.out.Interface())
+}
+
+func (f *fieldValue) IsBoolFlag() bool {
+	return f.out.Kind() == reflect.Bool
+}
+
+func (s *sliceFieldValue) Set(value string) error {
+	parts := splitValue(value, s.split)
+	elems := reflect.MakeSlice(s.out.Type(), len(parts), len(parts))
+
+	for index, part := range parts {
+		if err := setField(elems.Index(index), part); nil != err {
+			return err
+		}
+	}
+
+	if s.fresh {
+		s.out.Set(reflect.MakeSlice(s.out.Type(), 0, len(parts)))
+		s.fresh = false
+	}
+
+	s.out.Set(reflect.AppendSlice(s.out, elems))
+
+	return nil
+}
+
+func (s *sliceFieldValue) Get() interface{} {
+	return s.out.Interface()
+}
+
+func (s *sliceFieldValue) String() string {
+	elems := make([]string, s.out.Len())
+
+	for index := range elems {
+		elems[index] = fmt.Sprint(s.out.Index(index).Interface())
+	}
+
+	return strings.Join(elems, bindSliceSep)
+}
+
+func (s *sliceFieldValue) IsRepeatable() bool {
+	return true
+}
+
+func (s *sliceFieldValue) reset() {
+	s.out.Set(copySlice(s.defaults))
+	s.fresh = true
+}
+
+func copySlice(slice reflect.Value) reflect.Value {
+	return reflect.AppendSlice(reflect.MakeSlice(slice.Type(), 0, slice.Len()), slice)
+}
+
+// Whether the value is a struct holding further fields to bind.
+func isNestedStruct(out reflect.Value) bool {
+	return out.Kind() == reflect.Struct && false == out.Addr().Type().Implements(valueType)
+}
+
+// Whether setField supports the type.
+func bindableKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// Parse the string according to the kind of the value.
+func setField(out reflect.Value, value string) error {
+	switch out.Kind() {
+	case reflect.String:
+		out.SetString(value)
+	case reflect.Bool:
+		if b, ok := booleans[value]; ok {
+			out.SetBool(b)
+		} else {
+			return fmt.Errorf("'%s' is not a valid boolean value.", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if out.Type() == durationType {
+			d, err := time.ParseDuration(value)
+
+			if nil != err {
+				return fmt.Errorf("'%s' is not a valid duration.", value)
+			}
+
+			out.SetInt(int64(d))
+		} else if i, err := strconv.ParseInt(value, 0, out.Type().Bits()); nil == err {
+			out.SetInt(i)
+		} else {
+			return fmt.Errorf("'%s' is not a valid integer value.", value)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(value, 0, out.Type().Bits()); nil == err {
+			out.SetUint(u)
+		} else {
+			return fmt.Errorf("'%s' is not a valid unsigned integer value.", value)
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, out.Type().Bits()); nil == err {
+			out.SetFloat(f)
+		} else {
+			return fmt.Errorf("'%s' is not a valid number.", value)
+		}
+	}
+
+	return nil
+}
+
+// Derive a flag name from a field name, e.g. "HTTPPort" becomes
+// "http-port".
+func fieldFlagName(field string) string {
+	runes := []rune(field)
+	name := make([]rune, 0, len(runes)+4)
+
+	for index, r := range runes {
+		if index > 0 && unicode.IsUpper(r) {
+			prev := runes[index-1]
+			next := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				name = append(name, '-')
+			}
+		}
+
+		name = append(name, unicode.ToLower(r))
+	}
+
+	return string(name)
+}
@@ -0,0 +1,200 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"assert"
+)
+
+type bindTestOptions struct {
+	Verbose bool   `short:"v" desc:"Verbose output"`
+	Config  string `flag:"config" desc:"Configuration" env:"APPRC" value:"FILE"`
+	Level   int    `value:"LEVEL,optional" default:"2"`
+	Tags    []string
+	Ignored string `flag:"-"`
+	DB      struct {
+		Host string `default:"localhost"`
+		Port uint16 `default:"5432"`
+	}
+	Test struct {
+		Jobs    int           `flag:"jobs" value:"NUM" required:"true" group:"Execution"`
+		Timeout time.Duration `default:"1m"`
+		Ratio   float64
+		Cmd     *Command
+	} `command:"test" desc:"Run the test suite"`
+	Remote *Command `command:"remote" desc:"Manage remotes"`
+
+	hidden string
+}
+
+func TestBind(t *testing.T) {
+	var options bindTestOptions
+
+	unit := NewGNUParser("testing", false)
+
+	assert.True(t, "bind", nil == unit.Bind(&options))
+	assert.StringArrayEquals(t, "flags", unit.flagOrder, []string{"verbose", "config", "level", "tags", "db-host", "db-port"})
+	assert.StringArrayEquals(t, "commands", unit.cmdOrder, []string{"test", "remote"})
+	assert.Equals(t, "defaults", options.Level, 2)
+	assert.Equals(t, "nested default", options.DB.Host, "localhost")
+	assert.Equals(t, "default string", unit.flags["db-port"].defValue, "5432")
+	assert.Equals(t, "short", unit.flags["verbose"].short, 'v')
+	assert.Equals(t, "env", unit.flags["config"].env, "APPRC")
+	assert.Equals(t, "value name", unit.flags["config"].valueName, "FILE")
+	assert.True(t, "value required", unit.flags["config"].valueReq)
+	assert.False(t, "value optional", unit.flags["level"].valueReq)
+	assert.True(t, "command", options.Test.Cmd == unit.cmds["test"])
+	assert.True(t, "command field", options.Remote == unit.cmds["remote"])
+
+	jobs := unit.cmds["test"].flags["jobs"]
+
+	assert.True(t, "required", jobs.required)
+	assert.Equals(t, "group", jobs.group, "Execution")
+	assert.Equals(t, "description", unit.cmds["test"].desc, "Run the test suite")
+
+	err := unit.ParseArgs([]string{"-v", "--tags=a", "--tags", "b", "--db-port=1", "test", "--jobs", "4", "--timeout=2s", "--ratio=0.5"})
+
+	assert.True(t, "parse", nil == err)
+	assert.True(t, "bool", options.Verbose)
+	assert.StringArrayEquals(t, "slice", options.Tags, []string{"a", "b"})
+	assert.Equals(t, "uint", options.DB.Port, uint16(1))
+	assert.Equals(t, "int", options.Test.Jobs, 4)
+	assert.Equals(t, "duration", options.Test.Timeout, 2*time.Second)
+	assert.Equals(t, "float", options.Test.Ratio, 0.5)
+	assert.True(t, "triggered", unit.Triggered(options.Test.Cmd))
+
+	err = unit.ParseArgs([]string{"--db-port=70000"})
+
+	assert.True(t, "range", errors.Is(err, ErrInvalidValue))
+}
+
+func TestBindSliceDefault(t *testing.T) {
+	var options struct {
+		Ports []int `default:"80"`
+	}
+
+	unit := NewParser("testing", false)
+	unit.Bind(&options)
+
+	assert.Equals(t, "default", len(options.Ports), 1)
+	assert.True(t, "parse", nil == unit.ParseArgs([]string{"-ports=1", "-ports=2"}))
+	assert.Equals(t, "replaced", options.Ports, []int{1, 2})
+	assert.True(t, "reparse", nil == unit.ParseArgs([]string{"-ports=3"}))
+	assert.Equals(t, "reparsed", options.Ports, []int{3})
+	assert.True(t, "empty", nil == unit.ParseArgs([]string{}))
+	assert.Equals(t, "restored", options.Ports, []int{80})
+}
+
+func TestBindSlicePrefilled(t *testing.T) {
+	var options struct {
+		Tags []string
+	}
+
+	options.Tags = []string{"latest"}
+
+	unit := NewParser("testing", false)
+	unit.Bind(&options)
+
+	assert.True(t, "parse", nil == unit.ParseArgs([]string{"-tags=a"}))
+	assert.StringArrayEquals(t, "replaced", options.Tags, []string{"a"})
+	assert.True(t, "empty", nil == unit.ParseArgs([]string{}))
+	assert.StringArrayEquals(t, "restored", options.Tags, []string{"latest"})
+}
+
+func TestBindCommand(t *testing.T) {
+	var options struct {
+		Force bool
+		Cmd   *Command
+	}
+
+	unit := NewParser("testing", false)
+	cmd := unit.Command("test", "test command")
+
+	assert.True(t, "bind", nil == cmd.Bind(&options))
+	assert.True(t, "command", options.Cmd == cmd)
+	assert.True(t, "flag", nil != cmd.flags["force"])
+}
+
+type bindTestCommon struct {
+	Verbose bool
+}
+
+func TestBindEmbedded(t *testing.T) {
+	var options struct {
+		bindTestCommon
+		Log struct {
+			bindTestCommon
+		}
+	}
+
+	unit := NewParser("testing", false)
+
+	assert.True(t, "bind", nil == unit.Bind(&options))
+	assert.StringArrayEquals(t, "flags", unit.flagOrder, []string{"verbose", "log-verbose"})
+	assert.True(t, "parse", nil == unit.ParseArgs([]string{"-verbose", "-log-verbose"}))
+	assert.True(t, "embedded", options.Verbose)
+	assert.True(t, "nested embedded", options.Log.Verbose)
+}
+
+func TestBindParserCommand(t *testing.T) {
+	var options struct {
+		Status *Command `desc:"Show the status"`
+	}
+
+	unit := NewParser("testing", false)
+
+	assert.True(t, "bind", nil == unit.Bind(&options))
+	assert.True(t, "command", nil != options.Status && options.Status == unit.cmds["status"])
+	assert.Equals(t, "description", options.Status.desc, "Show the status")
+}
+
+func TestBindSliceSplit(t *testing.T) {
+	var options struct {
+		Tags  []string `split:","`
+		Ports []int    `split:"," default:"80,443"`
+	}
+
+	unit := NewParser("testing", false)
+
+	assert.True(t, "bind", nil == unit.Bind(&options))
+	assert.Equals(t, "default", options.Ports, []int{80, 443})
+	assert.True(t, "parse", nil == unit.ParseArgs([]string{"-tags=a,b", "-tags=c", "-ports=1,2"}))
+	assert.StringArrayEquals(t, "tags", options.Tags, []string{"a", "b", "c"})
+	assert.Equals(t, "ports", options.Ports, []int{1, 2})
+	assert.True(t, "invalid", nil != unit.ParseArgs([]string{"-ports=1,x"}))
+	assert.Equals(t, "rejected", options.Ports, []int{80, 443})
+}
+
+func TestBindErrors(t *testing.T) {
+	var options struct {
+		Channel chan int
+	}
+	var defaults struct {
+		Jobs int `default:"many"`
+	}
+
+	unit := NewParser("testing", false)
+
+	assert.Equals(t, "pointer", unit.Bind(options).Error(), "Unable to bind struct { Channel chan int }, expected a pointer to a struct")
+	assert.Equals(t, "type", unit.Bind(&options).Error(), "Unable to bind field Channel of type chan int")
+	assert.Equals(t, "default", unit.Bind(&defaults).Error(), "Invalid default value of field Jobs: 'many' is not a valid integer value.")
+}
+
+func TestFieldFlagName(t *testing.T) {
+	var tests = []struct {
+		field    string
+		expected string
+	}{
+		{"Jobs", "jobs"},
+		{"MaxJobs", "max-jobs"},
+		{"HTTPPort", "http-port"},
+		{"DB", "db"},
+		{"Level2Cache", "level2-cache"},
+	}
+
+	for _, test := range tests {
+		assert.Equals(t, test.field, fieldFlagName(test.field), test.expected)
+	}
+}
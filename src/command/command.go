@@ -3,22 +3,87 @@ package command
 type Command struct {
 	// dynamic initialization data
 
-	flags map[string]*Flag
-	desc  string
+	name      string
+	desc      string
+	category  string
+	flags     map[string]*Flag
+	cmds      map[string]*Command
+	flagOrder []string
+	cmdOrder  []string
+	parent    *Command
+	owner     *Parser
+	action    Handler
+	hooks     hooks
 
 	// dynamic runtime data
 
 	args []string
 }
 
+// Register a nested command. It can only be triggered after its
+// parent command has been matched on the command-line and before
+// any arguments have been assigned to the parent.
+func (c *Command) Command(name string, description string) *Command {
+	cmd := newCommand(name, description, c, c.owner)
+
+	c.cmdOrder = register(c.cmdOrder, name, c.cmds[name] != nil)
+	c.cmds[name] = cmd
+
+	return cmd
+}
+
 func (c *Command) Flag(name string, description string) *Flag {
 	flag := newFlag(description)
 
+	c.flagOrder = register(c.flagOrder, name, c.flags[name] != nil)
 	c.flags[name] = flag
 
 	return flag
 }
 
+// Assign the command to a category. Commands of the same category
+// share a heading in the usage message.
+func (c *Command) Category(name string) *Command {
+	c.category = name
+
+	return c
+}
+
+// Assign the function run by Parser.Run if the command has been
+// triggered. Commands without an action fall back to the action
+// of their nearest ancestor.
+func (c *Command) Action(action Handler) *Command {
+	c.action = action
+
+	return c
+}
+
+// Register a hook run before the action if the command has been
+// triggered, either directly or through a nested command. Hooks of
+// parent commands run first.
+func (c *Command) Before(hook Handler) *Command {
+	c.hooks.before = append(c.hooks.before, hook)
+
+	return c
+}
+
+// Register a hook run after the action if the command has been
+// triggered, even if the action or a Before hook failed. Hooks of
+// nested commands run first.
+func (c *Command) After(hook Handler) *Command {
+	c.hooks.after = append(c.hooks.after, hook)
+
+	return c
+}
+
+// Wrap the action if the command has been triggered. Middleware of
+// parent commands wraps that of nested commands.
+func (c *Command) Use(middleware ...Middleware) *Command {
+	c.hooks.middleware = append(c.hooks.middleware, middleware...)
+
+	return c
+}
+
 // Returns the number of arguments remaining
 // after flags have been processed
 func (c *Command) NArg() int {
@@ -29,13 +94,109 @@ func (c *Command) Args() []string {
 	return c.args
 }
 
+// Find a flag visible to the command along with its current value
+// (see Getter). Flags of the command take precedence over those of
+// its ancestors and the application. If no such flag exists, both
+// return values are nil.
+func (c *Command) Lookup(name string) (*Flag, interface{}) {
+	if flag := c.lookup(name); nil != flag {
+		return flag, getValue(flag.value)
+	}
+
+	return nil, nil
+}
+
+// Returns the name the command has been registered with.
+func (c *Command) Name() string {
+	return c.name
+}
+
+// Returns the command this command has been registered with or
+// nil for top-level commands.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Returns the description of the command.
+func (c *Command) Description() string {
+	return c.desc
+}
+
 func (c *Command) String() string {
 	return c.desc
 }
 
-func newCommand(description string) *Command {
+// Find a flag visible to the command. The flags of the command
+// itself take precedence over those of its ancestors, which in turn
+// take precedence over the application flags.
+func (c *Command) lookup(name string) *Flag {
+	for cmd := c; nil != cmd; cmd = cmd.parent {
+		if flag, ok := cmd.flags[name]; ok {
+			return flag
+		}
+	}
+
+	if nil != c.owner {
+		return c.owner.flags[name]
+	}
+
+	return nil
+}
+
+// Find a flag visible to the command by its single character
+// name. The precedence is the same as for lookup.
+func (c *Command) lookupShort(short rune) *Flag {
+	for cmd := c; nil != cmd; cmd = cmd.parent {
+		if flag := lookupShort(short, cmd.flags); nil != flag {
+			return flag
+		}
+	}
+
+	if nil != c.owner {
+		return lookupShort(short, c.owner.flags)
+	}
+
+	return nil
+}
+
+// Returns the chain of commands from the top-level command
+// down to this command (inclusive).
+func (c *Command) path() []*Command {
+	path := []*Command{}
+
+	for cmd := c; nil != cmd; cmd = cmd.parent {
+		path = append([]*Command{cmd}, path...)
+	}
+
+	return path
+}
+
+// Clear the runtime data of the command tree.
+func (c *Command) reset() {
+	for _, flag := range c.flags {
+		flag.clear()
+	}
+
+	for _, cmd := range c.cmds {
+		cmd.reset()
+	}
+}
+
+func newCommand(name string, description string, parent *Command, owner *Parser) *Command {
 	flags := make(map[string]*Flag)
+	cmds := make(map[string]*Command)
 	args := []string{}
 
-	return &Command{flags, description, args}
+	return &Command{name,
+		description,
+		"",
+		flags,
+		cmds,
+		[]string{},
+		[]string{},
+		parent,
+		owner,
+		nil,
+		hooks{},
+		args}
 }
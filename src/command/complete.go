@@ -0,0 +1,207 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// Hidden entry point used by the completion scripts. The
+	// arguments following it form the partial command-line, the
+	// last one being the word under completion.
+	CompletionCommand = "__complete"
+)
+
+// Hints for the shell on how to treat the completion candidates.
+// The directive is written as last line of the candidate list in
+// the form ":N".
+type CompletionDirective int
+
+const (
+	CompleteDefault CompletionDirective = 0
+	// do not append a space after the candidate
+	CompleteNoSpace CompletionDirective = 1
+	// do not fall back to paths if there are no candidates
+	CompleteNoFileFallback CompletionDirective = 2
+	// complete paths instead of the candidates
+	CompleteFiles CompletionDirective = 4
+	// complete directories instead of the candidates
+	CompleteDirs CompletionDirective = 8
+)
+
+type completionCandidate struct {
+	value string
+	desc  string
+}
+
+// Inspects the flags of a partial command-line without assigning
+// any values. It remembers the flag awaiting its value in the last
+// argument.
+type flagInspector struct {
+	last    int
+	pending *Flag
+}
+
+// Write the completion candidates for the partial command-line argv,
+// one per line with an optional description separated by a tab. The
+// last element of argv is the word being completed; it may be empty.
+// The candidates are followed by the directive line.
+func (p *Parser) WriteCandidates(out io.Writer, argv []string) {
+	candidates, directive := p.complete(argv)
+
+	for _, candidate := range candidates {
+		if len(candidate.desc) > 0 {
+			fmt.Fprintf(out, "%s\t%s\n", candidate.value, candidate.desc)
+		} else {
+			fmt.Fprintln(out, candidate.value)
+		}
+	}
+
+	fmt.Fprintf(out, ":%d\n", directive)
+}
+
+// Proxy method for WriteCandidates using os.Stdout as output writer.
+func (p *Parser) PrintCandidates(argv []string) {
+	p.WriteCandidates(os.Stdout, argv)
+}
+
+// Determine what is expected at the end of the partial command-line:
+// a value for a flag, a flag or a command (argument). The preceding
+// arguments are processed like ParseArgs does, but flag values are
+// never assigned and errors are ignored.
+func (p *Parser) complete(argv []string) ([]completionCandidate, CompletionDirective) {
+	var unknown *UnknownCommandError
+
+	if len(argv) == 0 {
+		argv = []string{""}
+	}
+
+	cur := argv[len(argv)-1]
+	e := newErrorCollector()
+	inspector := &flagInspector{len(argv) - 2, nil}
+	cmd, args, rest := p.walk(argv[:len(argv)-1], inspector, e)
+
+	if nil != rest {
+		// only arguments from here on
+		return nil, CompleteDefault
+	} else if errors.As(e.Error(), &unknown) {
+		return nil, CompleteNoFileFallback
+	} else if nil != inspector.pending {
+		return completeValue(inspector.pending, cur, "")
+	} else if strings.HasPrefix(cur, flagPrefix) {
+		if pos := strings.Index(cur, flagValueSep); pos >= 0 {
+			if flag := p.attachedFlag(cur[:pos], cmd); nil != flag {
+				return completeValue(flag, cur[pos+1:], cur[:pos+1])
+			}
+
+			return nil, CompleteNoFileFallback
+		}
+
+		return p.completeFlags(cur, cmd)
+	}
+
+	return p.completeCommands(cur, cmd, len(args))
+}
+
+func (p *Parser) completeFlags(cur string, cmd *Command) ([]completionCandidate, CompletionDirective) {
+	candidates := []completionCandidate{}
+	directive := CompleteNoFileFallback | CompleteNoSpace
+
+	for _, flag := range p.completionFlags(cmd) {
+		notations := flag.notations(p.gnu)
+		notations[0] = flag.candidate(p.gnu)
+
+		for _, notation := range notations {
+			if strings.HasPrefix(notation, cur) {
+				candidates = append(candidates, completionCandidate{notation, flag.hint()})
+
+				if false == strings.HasSuffix(notation, flagValueSep) {
+					directive &^= CompleteNoSpace
+				}
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		directive &^= CompleteNoSpace
+	}
+
+	return candidates, directive
+}
+
+func (p *Parser) completeCommands(cur string, cmd *Command, args int) ([]completionCandidate, CompletionDirective) {
+	candidates := []completionCandidate{}
+
+	if nil == cmd {
+		// the application itself accepts no arguments
+		for _, name := range p.cmdOrder {
+			if strings.HasPrefix(name, cur) {
+				candidates = append(candidates, completionCandidate{name, p.cmds[name].desc})
+			}
+		}
+
+		return candidates, CompleteNoFileFallback
+	} else if args == 0 {
+		for _, name := range cmd.cmdOrder {
+			if strings.HasPrefix(name, cur) {
+				candidates = append(candidates, completionCandidate{name, cmd.cmds[name].desc})
+			}
+		}
+	}
+
+	return candidates, CompleteDefault
+}
+
+// Complete the value of the given flag. Candidates are prefixed with
+// the flag notation if the value is attached to it.
+func completeValue(flag *Flag, value string, prefix string) ([]completionCandidate, CompletionDirective) {
+	var choices []string = nil
+
+	if nil != flag.complete {
+		choices = flag.complete(value)
+	} else if f, ok := flag.value.(*fileValue); ok && f.dir {
+		return nil, CompleteDirs
+	} else if ok {
+		return nil, CompleteFiles
+	} else if c, ok := flag.value.(choiceValue); ok {
+		choices = c.Choices()
+	}
+
+	candidates := []completionCandidate{}
+
+	for _, choice := range choices {
+		if strings.HasPrefix(choice, value) {
+			candidates = append(candidates, completionCandidate{prefix + choice, ""})
+		}
+	}
+
+	return candidates, CompleteNoFileFallback
+}
+
+// Returns the flag of the given notation (without value), which
+// accepts an attached value.
+func (p *Parser) attachedFlag(notation string, cmd *Command) *Flag {
+	if false == p.gnu {
+		return p.scope(cmd)(strings.TrimPrefix(notation, flagPrefix))
+	} else if strings.HasPrefix(notation, flagLongPrefix) {
+		return p.scope(cmd)(strings.TrimPrefix(notation, flagLongPrefix))
+	}
+
+	return nil
+}
+
+func (i *flagInspector) assign(name string, flag *Flag, val string, input string, index int) error {
+	return nil
+}
+
+func (i *flagInspector) missing(flag *Flag, index int) {
+	if index == i.last {
+		i.pending = flag
+	}
+}
+
+func (i *flagInspector) omitted(flag *Flag, index int) {
+}
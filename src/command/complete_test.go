@@ -0,0 +1,127 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"assert"
+)
+
+func newCompleteTestUnit(gnu bool) *Parser {
+	unit := newCompletionTestUnit(gnu)
+	cmd := unit.Command("remote", "remote command")
+
+	unit.Flag("verbose", "test flag").Short('v').Bool(false)
+	cmd.Flag("name", "remote name").Short('n').Value("NAME", true).Complete(func(prefix string) []string {
+		return []string{"origin", "upstream", "other"}
+	})
+
+	return unit
+}
+
+func writeCandidatesTest(unit *Parser, argv ...string) []string {
+	buf := new(bytes.Buffer)
+
+	unit.WriteCandidates(buf, argv)
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestCompleteCommands(t *testing.T) {
+	unit := newCompleteTestUnit(false)
+
+	var tests = []struct {
+		argv     []string
+		expected []string
+	}{
+		{[]string{}, []string{"test\ttest command", "remote\tremote command", ":2"}},
+		{[]string{"re"}, []string{"remote\tremote command", ":2"}},
+		{[]string{"-verbose", "t"}, []string{"test\ttest command", ":2"}},
+		{[]string{"test", ""}, []string{"nested\tnested command", ":0"}},
+		{[]string{"test", "arg", ""}, []string{":0"}},
+		{[]string{"test", "--", "-"}, []string{":0"}},
+		{[]string{"unknown", ""}, []string{":2"}},
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, strings.Join(test.argv, " "), writeCandidatesTest(unit, test.argv...), test.expected)
+	}
+}
+
+func TestCompleteFlags(t *testing.T) {
+	unit := newCompleteTestUnit(false)
+
+	var tests = []struct {
+		argv     []string
+		expected []string
+	}{
+		{[]string{"-c"}, []string{"-config=\tconfig file (=VAL)", ":3"}},
+		{[]string{"-v"}, []string{"-verbose\ttest flag", ":2"}},
+		{[]string{"test", "-o"}, []string{"-out=\toutput directory (=VAL)", ":3"}},
+		{[]string{"-x"}, []string{":2"}},
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, strings.Join(test.argv, " "), writeCandidatesTest(unit, test.argv...), test.expected)
+	}
+
+	unit = newCompleteTestUnit(true)
+
+	assert.StringArrayEquals(t, "gnu", writeCandidatesTest(unit, "-"), []string{
+		"--config=\tconfig file (=VAL)",
+		"-c\tconfig file (=VAL)",
+		"--mode=\tmode flag (=VAL)",
+		"--verbose\ttest flag",
+		"-v\ttest flag",
+		":2"})
+}
+
+func TestCompleteValues(t *testing.T) {
+	unit := newCompleteTestUnit(false)
+
+	var tests = []struct {
+		argv     []string
+		expected []string
+	}{
+		{[]string{"-mode=s"}, []string{"-mode=safe", ":2"}},
+		{[]string{"-config="}, []string{":4"}},
+		{[]string{"test", "-out="}, []string{":8"}},
+		{[]string{"remote", "-name", "o"}, []string{"origin", "other", ":2"}},
+		{[]string{"remote", "-name=u"}, []string{"-name=upstream", ":2"}},
+		{[]string{"-mode", "s"}, []string{":2"}}, // optional value
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, strings.Join(test.argv, " "), writeCandidatesTest(unit, test.argv...), test.expected)
+	}
+
+	unit = newCompleteTestUnit(true)
+
+	assert.StringArrayEquals(t, "short", writeCandidatesTest(unit, "remote", "-vn", ""), []string{"origin", "upstream", "other", ":2"})
+	assert.StringArrayEquals(t, "attached", writeCandidatesTest(unit, "remote", "-nx", ""), []string{":0"})
+	assert.StringArrayEquals(t, "long", writeCandidatesTest(unit, "remote", "--name=ot"), []string{"--name=other", ":2"})
+}
+
+func TestCompletionCommand(t *testing.T) {
+	unit := newCompleteTestUnit(false)
+	name := unit.Flag("name", "test flag").Value("NAME", true).Complete(func(prefix string) []string {
+		return []string{}
+	})
+
+	called := false
+
+	unit.Action(func(ctx *Context) error {
+		called = true
+
+		return nil
+	})
+
+	defer silenceStdout()()
+
+	assert.Equals(t, "flag", name, unit.flags["name"])
+	assert.True(t, "run", nil == unit.Run([]string{CompletionCommand, "-name", "x"}))
+	assert.False(t, "action", called)
+	assert.False(t, "present", unit.flags["name"].present)
+	assert.True(t, "parse", nil != unit.ParseArgs([]string{CompletionCommand}))
+}
@@ -0,0 +1,678 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+const (
+	// kinds of flag values with dedicated completion
+	completeNone = iota
+	completeAny
+	completeFile
+	completeDir
+	completeChoices
+	completeDynamic
+)
+
+var (
+	// characters not allowed in shell function names
+	shellIdentifier = regexp.MustCompile("[^A-Za-z0-9_]")
+)
+
+// A command (or the application itself) as seen by the shell. The
+// path consists of the command names separated by slashes, e.g.
+// "/remote/add". The application has an empty path.
+type completionNode struct {
+	path  string
+	cmds  []completionCommand
+	flags []completionFlag
+}
+
+type completionCommand struct {
+	name string
+	desc string
+}
+
+type completionFlag struct {
+	name      string
+	short     rune
+	desc      string
+	valueName string
+	valueReq  bool
+	kind      int
+	choices   []string
+}
+
+// Write a completion script for the given shell (bash, zsh or fish).
+// The script covers commands, the flags visible to each command and
+// flag values, i.e. paths for file and directory flags and the choices
+// of enumerations.
+func (p *Parser) WriteCompletion(out io.Writer, shell string) error {
+	nodes := p.completionNodes()
+	name := shellIdentifier.ReplaceAllString(p.owner, "_")
+
+	switch shell {
+	case ShellBash:
+		writeBashCompletion(out, p.owner, name, p.gnu, nodes)
+	case ShellZsh:
+		writeZshCompletion(out, p.owner, name, p.gnu, nodes)
+	case ShellFish:
+		writeFishCompletion(out, p.owner, name, p.gnu, nodes)
+	default:
+		return fmt.Errorf("Unsupported shell '%s'", shell)
+	}
+
+	return nil
+}
+
+// Flatten the command tree. Each node contains the flags visible to
+// the command, with flags of nested commands shadowing others.
+func (p *Parser) completionNodes() []completionNode {
+	nodes := []completionNode{completionNode{"", completionCommands(p.cmdOrder, p.cmds), p.completionFlags(nil)}}
+
+	for _, name := range p.cmdOrder {
+		nodes = p.appendCompletionNodes(nodes, "/"+name, p.cmds[name])
+	}
+
+	return nodes
+}
+
+func (p *Parser) appendCompletionNodes(nodes []completionNode, path string, cmd *Command) []completionNode {
+	nodes = append(nodes, completionNode{path, completionCommands(cmd.cmdOrder, cmd.cmds), p.completionFlags(cmd)})
+
+	for _, name := range cmd.cmdOrder {
+		nodes = p.appendCompletionNodes(nodes, path+"/"+name, cmd.cmds[name])
+	}
+
+	return nodes
+}
+
+// Collect the flags visible to the command. Without a command only
+// the application flags are visible.
+func (p *Parser) completionFlags(cmd *Command) []completionFlag {
+	flags := []completionFlag{}
+	seen := make(map[string]bool)
+
+	for c := cmd; nil != c; c = c.parent {
+		flags = appendCompletionFlags(flags, c.flagOrder, c.flags, seen)
+	}
+
+	return appendCompletionFlags(flags, p.flagOrder, p.flags, seen)
+}
+
+func completionCommands(order []string, cmds map[string]*Command) []completionCommand {
+	commands := []completionCommand{}
+
+	for _, name := range order {
+		commands = append(commands, completionCommand{name, cmds[name].desc})
+	}
+
+	return commands
+}
+
+func appendCompletionFlags(flags []completionFlag, order []string, haystack map[string]*Flag, seen map[string]bool) []completionFlag {
+	for _, name := range order {
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		flag := haystack[name]
+		entry := completionFlag{name, flag.short, flag.desc, flag.valueName, flag.valueReq, completeAny, nil}
+
+		if isBoolFlag(flag.value) {
+			entry.kind = completeNone
+		} else if nil != flag.complete {
+			entry.kind = completeDynamic
+		} else if f, ok := flag.value.(*fileValue); ok && f.dir {
+			entry.kind = completeDir
+		} else if ok {
+			entry.kind = completeFile
+		} else if c, ok := flag.value.(choiceValue); ok {
+			entry.kind = completeChoices
+			entry.choices = c.Choices()
+		}
+
+		flags = append(flags, entry)
+	}
+
+	return flags
+}
+
+// Returns the notations of the flag accepted by the parser, e.g.
+// "-name" or "--name" and "-n".
+func (f *completionFlag) notations(gnu bool) []string {
+	if false == gnu {
+		return []string{flagPrefix + f.name}
+	} else if 0 != f.short {
+		return []string{flagLongPrefix + f.name, flagPrefix + string(f.short)}
+	}
+
+	return []string{flagLongPrefix + f.name}
+}
+
+// Returns the long notation of the flag including the value separator
+// if it accepts a value.
+func (f *completionFlag) candidate(gnu bool) string {
+	name := f.notations(gnu)[0]
+
+	if f.kind != completeNone {
+		return name + flagValueSep
+	}
+
+	return name
+}
+
+// Returns the description including the value hint (if any).
+func (f *completionFlag) hint() string {
+	if f.kind == completeNone {
+		return f.desc
+	}
+
+	return fmt.Sprintf("%s (%s%s)", f.desc, flagValueSep, f.valueName)
+}
+
+func writeBashCompletion(out io.Writer, app string, name string, gnu bool, nodes []completionNode) {
+	fmt.Fprintf(out, "# bash completion for %s\n\n", app)
+
+	if hasCompletion(nodes, completeDynamic) {
+		writeBashDynamic(out, name, gnu)
+	}
+
+	if hasCompletion(nodes, completeChoices) {
+		writeBashChoices(out, name)
+	}
+
+	fmt.Fprintf(out, "_%s_values() {\n", name)
+	fmt.Fprintln(out, `    local cmdpath="$1" flag="$2" value="$3" detached="$4"`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ -n "$detached" ]]; then`)
+	fmt.Fprintln(out, `        case "$cmdpath $flag" in`)
+	writeShellCases(out, "            ", nodes, gnu, true, func(flag *completionFlag) string {
+		return ";;"
+	})
+	fmt.Fprintln(out, `            *) return 1 ;;`)
+	fmt.Fprintln(out, `        esac`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    case "$cmdpath $flag" in`)
+	writeShellCases(out, "        ", nodes, gnu, false, func(flag *completionFlag) string {
+		switch flag.kind {
+		case completeFile:
+			return `COMPREPLY=($(compgen -f -- "$value")) ;;`
+		case completeDir:
+			return `COMPREPLY=($(compgen -d -- "$value")) ;;`
+		case completeChoices:
+			return fmt.Sprintf(`_%s_choices "$value" %s ;;`, name, strings.Join(quoteShellAll(flag.choices), " "))
+		case completeDynamic:
+			return fmt.Sprintf(`_%s_dynamic "$cmdpath" "$flag" "$value" ;;`, name)
+		}
+
+		return "COMPREPLY=() ;;"
+	})
+	fmt.Fprintln(out, `        *) return 1 ;;`)
+	fmt.Fprintln(out, `    esac`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+
+	fmt.Fprintf(out, "_%s_complete() {\n", name)
+	fmt.Fprintln(out, `    local cur prev cmdpath="" word flag value detached="" i`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(out, `    prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(out, `    COMPREPLY=()`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(out, `        word="${COMP_WORDS[i]}"`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "        if [[ \"$word\" == %s ]]; then\n", quoteShell(flagTermination))
+	fmt.Fprintln(out, `            COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(out, `            return 0`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out)
+	writeShellPathCase(out, nodes, `"$cmdpath/$word"`, `cmdpath="$cmdpath/$word" ;;`)
+	fmt.Fprintln(out, `    done`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ "$cur" == "=" ]]; then`)
+	fmt.Fprintln(out, `        flag="$prev"`)
+	fmt.Fprintln(out, `        value=""`)
+	fmt.Fprintln(out, `    elif [[ "$prev" == "=" && $COMP_CWORD -ge 2 ]]; then`)
+	fmt.Fprintln(out, `        flag="${COMP_WORDS[COMP_CWORD-2]}"`)
+	fmt.Fprintln(out, `        value="$cur"`)
+	fmt.Fprintln(out, `    elif [[ "$cur" == -*=* ]]; then`)
+	fmt.Fprintln(out, `        flag="${cur%%=*}"`)
+	fmt.Fprintln(out, `        value="${cur#*=}"`)
+	fmt.Fprintln(out, `    else`)
+	fmt.Fprintln(out, `        flag="$prev"`)
+	fmt.Fprintln(out, `        value="$cur"`)
+	fmt.Fprintln(out, `        detached=1`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "    if _%s_values \"$cmdpath\" \"$flag\" \"$value\" \"$detached\"; then\n", name)
+	fmt.Fprintln(out, `        if [[ "$cur" == -*=* ]]; then`)
+	fmt.Fprintln(out, `            COMPREPLY=("${COMPREPLY[@]/#/$flag=}")`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out, `        return 0`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(out, `        case "$cmdpath" in`)
+
+	for _, node := range nodes {
+		candidates := []string{}
+
+		for _, flag := range node.flags {
+			candidates = append(candidates, flag.candidate(gnu))
+		}
+
+		fmt.Fprintf(out, "            %s) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n",
+			quoteShell(node.path), quoteShell(strings.Join(candidates, " ")))
+	}
+
+	fmt.Fprintln(out, `        esac`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `        if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == *= ]]; then`)
+	fmt.Fprintln(out, `            compopt -o nospace 2>/dev/null`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out, `        return 0`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    case "$cmdpath" in`)
+
+	for _, node := range nodes {
+		if len(node.cmds) > 0 {
+			names := []string{}
+
+			for _, cmd := range node.cmds {
+				names = append(names, cmd.name)
+			}
+
+			fmt.Fprintf(out, "        %s) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n",
+				quoteShell(node.path), quoteShell(strings.Join(names, " ")))
+		}
+	}
+
+	fmt.Fprintln(out, `    esac`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ ${#COMPREPLY[@]} -eq 0 && -n "$cmdpath" ]]; then`)
+	fmt.Fprintln(out, `        COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "complete -F _%s_complete %s\n", name, app)
+}
+
+func writeZshCompletion(out io.Writer, app string, name string, gnu bool, nodes []completionNode) {
+	fmt.Fprintf(out, "#compdef %s\n\n", app)
+
+	if hasCompletion(nodes, completeDynamic) {
+		writeZshDynamic(out, name, gnu)
+	}
+
+	fmt.Fprintf(out, "_%s_values() {\n", name)
+	fmt.Fprintln(out, `    local cmdpath="$1" flag="$2" value="$3" detached="$4"`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ -n "$detached" ]]; then`)
+	fmt.Fprintln(out, `        case "$cmdpath $flag" in`)
+	writeShellCases(out, "            ", nodes, gnu, true, func(flag *completionFlag) string {
+		return ";;"
+	})
+	fmt.Fprintln(out, `            *) return 1 ;;`)
+	fmt.Fprintln(out, `        esac`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    case "$cmdpath $flag" in`)
+	writeShellCases(out, "        ", nodes, gnu, false, func(flag *completionFlag) string {
+		switch flag.kind {
+		case completeFile:
+			return "_files ;;"
+		case completeDir:
+			return "_files -/ ;;"
+		case completeChoices:
+			return fmt.Sprintf("compadd -- %s ;;", strings.Join(quoteShellAll(flag.choices), " "))
+		case completeDynamic:
+			return fmt.Sprintf(`_%s_dynamic "$cmdpath" "$flag" "$value" ;;`, name)
+		}
+
+		return fmt.Sprintf("_message %s ;;", quoteShell(flag.valueName))
+	})
+	fmt.Fprintln(out, `        *) return 1 ;;`)
+	fmt.Fprintln(out, `    esac`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+
+	fmt.Fprintf(out, "_%s() {\n", name)
+	fmt.Fprintln(out, `    local cur="${words[CURRENT]}" prev="${words[CURRENT-1]}" cmdpath="" word i`)
+	fmt.Fprintln(out, `    local -a flags valued cmds`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    for ((i = 2; i < CURRENT; i++)); do`)
+	fmt.Fprintln(out, `        word="${words[i]}"`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "        if [[ \"$word\" == %s ]]; then\n", quoteShell(flagTermination))
+	fmt.Fprintln(out, `            _files`)
+	fmt.Fprintln(out, `            return`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out)
+	writeShellPathCase(out, nodes, `"$cmdpath/$word"`, `cmdpath="$cmdpath/$word" ;;`)
+	fmt.Fprintln(out, `    done`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ "$cur" == -*=* ]]; then`)
+	fmt.Fprintln(out, `        compset -P '*='`)
+	fmt.Fprintf(out, "        _%s_values \"$cmdpath\" \"${cur%%%%=*}\" \"${cur#*=}\"\n", name)
+	fmt.Fprintln(out, `        return`)
+	fmt.Fprintf(out, "    elif _%s_values \"$cmdpath\" \"$prev\" \"$cur\" 1; then\n", name)
+	fmt.Fprintln(out, `        return`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    case "$cmdpath" in`)
+
+	for _, node := range nodes {
+		flags := []string{}
+		valued := []string{}
+		cmds := []string{}
+
+		for _, flag := range node.flags {
+			entry := quoteShell(escapeZsh(flag.candidate(gnu)) + ":" + flag.hint())
+
+			if flag.kind == completeNone {
+				flags = append(flags, entry)
+			} else {
+				valued = append(valued, entry)
+			}
+		}
+
+		for _, cmd := range node.cmds {
+			cmds = append(cmds, quoteShell(escapeZsh(cmd.name)+":"+cmd.desc))
+		}
+
+		fmt.Fprintf(out, "        %s)\n", quoteShell(node.path))
+		fmt.Fprintf(out, "            flags=(%s)\n", strings.Join(flags, " "))
+		fmt.Fprintf(out, "            valued=(%s)\n", strings.Join(valued, " "))
+		fmt.Fprintf(out, "            cmds=(%s)\n", strings.Join(cmds, " "))
+		fmt.Fprintln(out, "            ;;")
+	}
+
+	fmt.Fprintln(out, `    esac`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(out, `        _describe -t flags 'flag' flags`)
+	fmt.Fprintln(out, `        _describe -t flags 'flag' valued -S ''`)
+	fmt.Fprintln(out, `    elif (( ${#cmds} > 0 )); then`)
+	fmt.Fprintln(out, `        _describe -t commands 'command' cmds`)
+	fmt.Fprintln(out, `    elif [[ -n "$cmdpath" ]]; then`)
+	fmt.Fprintln(out, `        _files`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "compdef _%s %s\n", name, app)
+}
+
+func writeFishCompletion(out io.Writer, app string, name string, gnu bool, nodes []completionNode) {
+	fmt.Fprintf(out, "# fish completion for %s\n\n", app)
+
+	if hasCompletion(nodes, completeDynamic) {
+		writeFishDynamic(out, name)
+	}
+
+	fmt.Fprintf(out, "function __%s_path\n", name)
+	fmt.Fprintln(out, `    set -l cmdpath ""`)
+	fmt.Fprintln(out, `    for word in (commandline -opc)[2..-1]`)
+	fmt.Fprintf(out, "        if test \"$word\" = %s\n", quoteShell(flagTermination))
+	fmt.Fprintln(out, `            break`)
+	fmt.Fprintln(out, `        end`)
+	fmt.Fprintln(out, `        switch "$cmdpath/$word"`)
+
+	paths := []string{}
+
+	for _, node := range nodes[1:] {
+		paths = append(paths, quoteShell(node.path))
+	}
+
+	if len(paths) > 0 {
+		fmt.Fprintf(out, "            case %s\n", strings.Join(paths, " "))
+		fmt.Fprintln(out, `                set cmdpath "$cmdpath/$word"`)
+	}
+
+	fmt.Fprintln(out, `        end`)
+	fmt.Fprintln(out, `    end`)
+	fmt.Fprintln(out, `    test "$cmdpath" = "$argv[1]"`)
+	fmt.Fprintln(out, `end`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "complete -c %s -f\n", app)
+
+	for _, node := range nodes {
+		condition := quoteShell(fmt.Sprintf("__%s_path \"%s\"", name, node.path))
+
+		fmt.Fprintln(out)
+
+		for _, cmd := range node.cmds {
+			fmt.Fprintf(out, "complete -c %s -n %s -a %s -d %s\n",
+				app, condition, quoteShell(cmd.name), quoteShell(cmd.desc))
+		}
+
+		if len(node.cmds) == 0 && len(node.path) > 0 {
+			// command arguments
+			fmt.Fprintf(out, "complete -c %s -n %s -F\n", app, condition)
+		}
+
+		for _, flag := range node.flags {
+			option := fmt.Sprintf("-o %s", quoteShell(flag.name))
+
+			if gnu {
+				option = fmt.Sprintf("-l %s", quoteShell(flag.name))
+
+				if 0 != flag.short {
+					option += fmt.Sprintf(" -s %s", quoteShell(string(flag.short)))
+				}
+			}
+
+			switch flag.kind {
+			case completeFile:
+				option += " -r -F"
+			case completeDir:
+				option += " -x -a '(__fish_complete_directories)'"
+			case completeChoices:
+				option += fmt.Sprintf(" -x -a %s", quoteShell(strings.Join(quoteShellAll(flag.choices), " ")))
+			case completeDynamic:
+				option += fmt.Sprintf(" -x -a '(__%s_dynamic)'", name)
+			case completeAny:
+				option += " -x"
+			}
+
+			fmt.Fprintf(out, "complete -c %s -n %s %s -d %s\n", app, condition, option, quoteShell(flag.hint()))
+		}
+	}
+}
+
+// Complete the choices (following the value) starting with the value.
+// Choices are escaped as they may contain spaces.
+func writeBashChoices(out io.Writer, name string) {
+	fmt.Fprintf(out, "_%s_choices() {\n", name)
+	fmt.Fprintln(out, `    local value="$1" choice`)
+	fmt.Fprintln(out, `    shift`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    for choice in "$@"; do`)
+	fmt.Fprintln(out, `        if [[ "$choice" == "$value"* ]]; then`)
+	fmt.Fprintf(out, "            COMPREPLY+=(\"$(printf '%%q' \"$choice\")\")\n")
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out, `    done`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+}
+
+// Write the function passing the partial value of a flag to the
+// application (see CompletionCommand). The directive is honoured as
+// far as it applies to flag values.
+func writeBashDynamic(out io.Writer, name string, gnu bool) {
+	fmt.Fprintf(out, "_%s_dynamic() {\n", name)
+	fmt.Fprintln(out, `    local cmdpath="$1" flag="$2" value="$3" line directive=0`)
+	fmt.Fprintln(out, `    local -a argv=()`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    if [[ -n "$cmdpath" ]]; then`)
+	fmt.Fprintln(out, `        IFS=/ read -ra argv <<< "${cmdpath#/}"`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out)
+	writeDynamicArgv(out, gnu)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    while IFS= read -r line; do`)
+	fmt.Fprintln(out, `        if [[ "$line" == :* ]]; then`)
+	fmt.Fprintln(out, `            directive="${line#:}"`)
+	fmt.Fprintln(out, `        else`)
+	fmt.Fprintln(out, `            line="${line%%$'\t'*}"`)
+	fmt.Fprintln(out, `            COMPREPLY+=("${line#"$flag="}")`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintf(out, "    done < <(\"${COMP_WORDS[0]}\" %s \"${argv[@]}\" 2>/dev/null)\n", CompletionCommand)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "    if (( directive & %d )); then\n", CompleteNoSpace)
+	fmt.Fprintln(out, `        compopt -o nospace 2>/dev/null`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+}
+
+func writeZshDynamic(out io.Writer, name string, gnu bool) {
+	fmt.Fprintf(out, "_%s_dynamic() {\n", name)
+	fmt.Fprintln(out, `    local cmdpath="$1" flag="$2" value="$3" line directive=0`)
+	fmt.Fprintln(out, `    local -a argv candidates`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `    argv=(${(s:/:)cmdpath})`)
+	writeDynamicArgv(out, gnu)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "    for line in \"${(@f)$(\"${words[1]}\" %s \"${argv[@]}\" 2>/dev/null)}\"; do\n", CompletionCommand)
+	fmt.Fprintln(out, `        if [[ "$line" == :* ]]; then`)
+	fmt.Fprintln(out, `            directive="${line#:}"`)
+	fmt.Fprintln(out, `            continue`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `        line="${line#"$flag="}"`)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `        if [[ "$line" == *$'\t'* ]]; then`)
+	fmt.Fprintln(out, `            candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")`)
+	fmt.Fprintln(out, `        elif [[ -n "$line" ]]; then`)
+	fmt.Fprintln(out, `            candidates+=("${line//:/\\:}")`)
+	fmt.Fprintln(out, `        fi`)
+	fmt.Fprintln(out, `    done`)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "    if (( directive & %d )); then\n", CompleteNoSpace)
+	fmt.Fprintln(out, `        _describe -t values 'value' candidates -S ''`)
+	fmt.Fprintln(out, `    else`)
+	fmt.Fprintln(out, `        _describe -t values 'value' candidates`)
+	fmt.Fprintln(out, `    fi`)
+	fmt.Fprintln(out, `}`)
+	fmt.Fprintln(out)
+}
+
+func writeFishDynamic(out io.Writer, name string) {
+	fmt.Fprintf(out, "function __%s_dynamic\n", name)
+	fmt.Fprintln(out, `    set -l tokens (commandline -opc)`)
+	fmt.Fprintln(out, `    set -l token (commandline -ct)`)
+	fmt.Fprintln(out, `    set -l prefix (string match -r -- '^-[^=]*=' $token)`)
+	fmt.Fprintf(out, "    for line in ($tokens[1] %s $tokens[2..-1] $token 2>/dev/null)\n", CompletionCommand)
+	fmt.Fprintln(out, `        if string match -q -- ':*' $line`)
+	fmt.Fprintln(out, `            continue`)
+	fmt.Fprintln(out, `        else if test -n "$prefix"`)
+	fmt.Fprintln(out, `            string replace -- $prefix '' $line`)
+	fmt.Fprintln(out, `        else`)
+	fmt.Fprintln(out, `            string join -- '' $line`)
+	fmt.Fprintln(out, `        end`)
+	fmt.Fprintln(out, `    end`)
+	fmt.Fprintln(out, `end`)
+	fmt.Fprintln(out)
+}
+
+// Write the statement appending the flag and its partial value to
+// the argv array. Short GNU flags do not accept an attached value.
+func writeDynamicArgv(out io.Writer, gnu bool) {
+	if false == gnu {
+		fmt.Fprintln(out, `    argv+=("$flag=$value")`)
+		return
+	}
+
+	fmt.Fprintf(out, "    if [[ \"$flag\" == %s* ]]; then\n", flagLongPrefix)
+	fmt.Fprintln(out, `        argv+=("$flag=$value")`)
+	fmt.Fprintln(out, `    else`)
+	fmt.Fprintln(out, `        argv+=("$flag" "$value")`)
+	fmt.Fprintln(out, `    fi`)
+}
+
+// Whether any flag completes its value in the given way.
+func hasCompletion(nodes []completionNode, kind int) bool {
+	for _, node := range nodes {
+		for _, flag := range node.flags {
+			if flag.kind == kind {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Write the case patterns matching the flags accepting a value. The
+// patterns have the form "PATH FLAG". If detached is true, only flags
+// requiring a value are considered.
+func writeShellCases(out io.Writer, indent string, nodes []completionNode, gnu bool, detached bool, action func(*completionFlag) string) {
+	for _, node := range nodes {
+		for index := range node.flags {
+			flag := &node.flags[index]
+
+			if flag.kind == completeNone || (detached && false == flag.valueReq) {
+				continue
+			}
+
+			patterns := []string{}
+
+			for _, notation := range flag.notations(gnu) {
+				patterns = append(patterns, quoteShell(node.path+" "+notation))
+			}
+
+			fmt.Fprintf(out, "%s%s) %s\n", indent, strings.Join(patterns, "|"), action(flag))
+		}
+	}
+}
+
+// Write the case statement tracking the command path.
+func writeShellPathCase(out io.Writer, nodes []completionNode, subject string, action string) {
+	if len(nodes) < 2 {
+		return
+	}
+
+	paths := []string{}
+
+	for _, node := range nodes[1:] {
+		paths = append(paths, quoteShell(node.path))
+	}
+
+	fmt.Fprintf(out, "        case %s in\n", subject)
+	fmt.Fprintf(out, "            %s) %s\n", strings.Join(paths, "|"), action)
+	fmt.Fprintln(out, "        esac")
+}
+
+// Quote a string for POSIX-like shells and fish.
+func quoteShell(text string) string {
+	return "'" + strings.Replace(text, "'", `'\''`, -1) + "'"
+}
+
+func quoteShellAll(texts []string) []string {
+	quoted := make([]string, len(texts))
+
+	for index, text := range texts {
+		quoted[index] = quoteShell(text)
+	}
+
+	return quoted
+}
+
+// Escape the separator of names and descriptions used by _describe.
+func escapeZsh(text string) string {
+	return strings.Replace(text, ":", `\:`, -1)
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"assert"
+)
+
+func newCompletionTestUnit(gnu bool) *Parser {
+	var unit *Parser
+
+	if gnu {
+		unit = NewGNUParser("testing", false)
+	} else {
+		unit = NewParser("testing", false)
+	}
+
+	cmd := unit.Command("test", "test command")
+
+	unit.Flag("config", "config file").Short('c').File("")
+	unit.Flag("mode", "mode flag").Enum("fast", "fast", "safe", "very safe")
+	cmd.Flag("out", "output directory").Dir("")
+	cmd.Command("nested", "nested command")
+
+	return unit
+}
+
+func writeCompletionTest(t *testing.T, unit *Parser, shell string) string {
+	buf := new(bytes.Buffer)
+
+	if err := unit.WriteCompletion(buf, shell); nil != err {
+		t.Fatalf("unexpected error for %s: %v", shell, err)
+	}
+
+	return buf.String()
+}
+
+func TestBashCompletion(t *testing.T) {
+	script := writeCompletionTest(t, newCompletionTestUnit(false), ShellBash)
+
+	var expected = []string{
+		"complete -F _testing_complete testing",
+		"'/test'|'/test/nested'",
+		"compgen -W '-out= -config= -mode='",
+		`_testing_choices "$value" 'fast' 'safe' 'very safe' ;;`,
+		"compgen -d",
+	}
+
+	for _, text := range expected {
+		assert.True(t, text, strings.Contains(script, text))
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	script := writeCompletionTest(t, newCompletionTestUnit(true), ShellZsh)
+
+	var expected = []string{
+		"#compdef testing",
+		"compdef _testing testing",
+		"'--config=:config file (=VAL)'",
+		"'nested:nested command'",
+		"compadd -- 'fast' 'safe' 'very safe'",
+	}
+
+	for _, text := range expected {
+		assert.True(t, text, strings.Contains(script, text))
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	script := writeCompletionTest(t, newCompletionTestUnit(true), ShellFish)
+
+	var expected = []string{
+		"complete -c testing -n '__testing_path \"\"' -a 'test' -d 'test command'",
+		"-l 'config' -s 'c' -r -F",
+		`-l 'mode' -x -a ''\''fast'\'' '\''safe'\'' '\''very safe'\'''`,
+		"__fish_complete_directories",
+	}
+
+	for _, text := range expected {
+		assert.True(t, text, strings.Contains(script, text))
+	}
+}
+
+func TestUnsupportedShell(t *testing.T) {
+	err := newCompletionTestUnit(false).WriteCompletion(new(bytes.Buffer), "tcsh")
+
+	assert.Equals(t, "error", err.Error(), "Unsupported shell 'tcsh'")
+}
+
+func TestDynamicCompletion(t *testing.T) {
+	for _, shell := range []string{ShellBash, ShellZsh, ShellFish} {
+		unit := newCompletionTestUnit(true)
+
+		assert.False(t, shell, strings.Contains(writeCompletionTest(t, unit, shell), CompletionCommand))
+
+		unit.Flag("remote", "test flag").Value("NAME", true).Complete(func(prefix string) []string {
+			return []string{prefix}
+		})
+
+		script := writeCompletionTest(t, unit, shell)
+
+		assert.True(t, shell, strings.Contains(script, CompletionCommand))
+		assert.True(t, shell, strings.Contains(script, "_testing_dynamic"))
+	}
+}
@@ -0,0 +1,348 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	configSectionSep = "."
+	configJSONExt    = ".json"
+)
+
+// A key-value pair read from a configuration file. Entries without
+// a key denote the beginning of a section.
+type configEntry struct {
+	section []string
+	key     string
+	value   string
+	line    int
+}
+
+// Load flag values from the configuration file named by the given
+// application flag. Files ending in ".json" are read as JSON, any
+// other file as INI file. Top-level keys name application flags,
+// sections (or nested objects) name commands, e.g. "[remote.add]".
+// Values from the file apply to flags absent from the command-line
+// and the environment. The file is optional as long as the flag has
+// not been given explicitly.
+func (p *Parser) ConfigFlag(name string) {
+	p.config = name
+}
+
+// Assign the values of the configuration file to the flags of the
+// application and the triggered commands. The keys of other commands
+// are validated only.
+func (p *Parser) configure(path []*Command, e *errorTracker) {
+	file, ok := p.configPath()
+
+	if false == ok {
+		return
+	}
+
+	entries, err := readConfig(file)
+
+	if nil != err {
+		storeError(e, err)
+		return
+	}
+
+	configured := make(map[*Flag]bool)
+
+	for _, entry := range entries {
+		cmd, err := p.configSection(entry.section)
+
+		if nil != err || len(entry.key) == 0 {
+			if nil != err && len(entry.key) == 0 {
+				// reported once per section
+				storeError(e, &ConfigError{file, entry.line, err})
+			}
+		} else if err = p.configureFlag(cmd, path, file, entry, configured); nil != err {
+			if _, ok := err.(*ValuePanicError); false == ok {
+				err = &ConfigError{file, entry.line, err}
+			}
+
+			storeError(e, err)
+		}
+
+		if e.Halted() {
+			return
+		}
+	}
+}
+
+// Write the value of a configuration entry to the flag of the given
+// command (or the application). Flags already present are skipped,
+// unless the value stems from the configuration file itself.
+func (p *Parser) configureFlag(cmd *Command, path []*Command, file string, entry configEntry, configured map[*Flag]bool) error {
+	flags, order := p.flags, p.flagOrder
+
+	if nil != cmd {
+		flags, order = cmd.flags, cmd.flagOrder
+	}
+
+	flag := flags[entry.key]
+
+	if nil == flag {
+		return &UnknownFlagError{entry.key, entry.key, -1, suggest(entry.key, order, p.suggest)}
+	} else if false == configActive(cmd, path) || (flag.present && false == configured[flag]) {
+		return nil
+	}
+
+	if err := callSet(entry.key, flag.value, entry.value); nil != err {
+		if _, ok := err.(*ValuePanicError); ok {
+			return err
+		}
+
+		return &InvalidValueError{entry.key, entry.value, -1, entry.value, "", err}
+	}
+
+	configured[flag] = true
+	flag.mark(Provenance{SourceConfig, "", file, entry.line, -1})
+
+	return nil
+}
+
+// Returns the path of the configuration file. A missing file is
+// only reported if the config flag has been given explicitly.
+func (p *Parser) configPath() (string, bool) {
+	flag := p.flags[p.config]
+
+	if len(p.config) == 0 || nil == flag {
+		return "", false
+	}
+
+	file, ok := getValue(flag.value).(string)
+
+	if false == ok || len(file) == 0 {
+		return "", false
+	} else if _, err := os.Stat(file); nil != err && false == flag.present {
+		return "", false
+	}
+
+	return file, true
+}
+
+// Find the command of a section. The application has no section
+// name.
+func (p *Parser) configSection(names []string) (*Command, error) {
+	var cmd *Command = nil
+
+	cmds, order := p.cmds, p.cmdOrder
+
+	for _, name := range names {
+		if cmd = cmds[name]; nil == cmd {
+			input := strings.Join(names, configSectionSep)
+
+			return nil, &UnknownCommandError{name, input, -1, suggest(name, order, p.suggest)}
+		}
+
+		cmds, order = cmd.cmds, cmd.cmdOrder
+	}
+
+	return cmd, nil
+}
+
+// Whether the values of the command (or the application) apply,
+// i.e. whether the command is part of the triggered path.
+func configActive(cmd *Command, path []*Command) bool {
+	if nil == cmd {
+		return true
+	}
+
+	for _, c := range path {
+		if c == cmd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readConfig(file string) ([]configEntry, error) {
+	var entries []configEntry
+
+	data, err := os.ReadFile(file)
+
+	if nil != err {
+		return nil, &ConfigError{file, 0, err}
+	} else if strings.EqualFold(filepath.Ext(file), configJSONExt) {
+		entries, err = parseJSONConfig(data)
+	} else {
+		entries, err = parseINIConfig(data)
+	}
+
+	if c, ok := err.(*ConfigError); ok {
+		c.File = file
+	}
+
+	return entries, err
+}
+
+// Read an INI file. Lines starting with '#' or ';' are comments.
+// Values may be quoted; a key without value is an empty value.
+func parseINIConfig(data []byte) ([]configEntry, error) {
+	var section []string = nil
+
+	entries := []configEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if len(text) == 0 || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		} else if strings.HasPrefix(text, "[") {
+			if false == strings.HasSuffix(text, "]") {
+				return nil, &ConfigError{"", line, fmt.Errorf("Unterminated section '%s'", text)}
+			}
+
+			section = strings.Split(strings.TrimSpace(text[1:len(text)-1]), configSectionSep)
+
+			for index := range section {
+				section[index] = strings.TrimSpace(section[index])
+			}
+
+			entries = append(entries, configEntry{section, "", "", line})
+		} else {
+			parts := strings.SplitN(text, "=", 2)
+			value := ""
+
+			if len(parts) > 1 {
+				value = unquoteConfig(strings.TrimSpace(parts[1]))
+			}
+
+			entries = append(entries, configEntry{section, strings.TrimSpace(parts[0]), value, line})
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+func unquoteConfig(value string) string {
+	if len(value) < 2 {
+		return value
+	} else if first := value[0]; (first == '"' || first == '\'') && value[len(value)-1] == first {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// Read a JSON object. Nested objects are sections, arrays assign
+// each of their elements.
+func parseJSONConfig(data []byte) ([]configEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	line := func(offset int64) int {
+		return 1 + bytes.Count(data[:offset], []byte("\n"))
+	}
+
+	decoder.UseNumber()
+
+	entries, err := []configEntry(nil), expectJSONDelim(decoder, '{')
+
+	if nil == err {
+		entries, err = parseJSONObject(decoder, nil, line)
+	}
+
+	if syntax, ok := err.(*json.SyntaxError); ok {
+		return nil, &ConfigError{"", line(syntax.Offset), err}
+	} else if nil != err && false == errors.As(err, new(*ConfigError)) {
+		return nil, &ConfigError{"", line(decoder.InputOffset()), err}
+	}
+
+	return entries, err
+}
+
+// Read the members of an object up to (and including) its closing
+// brace.
+func parseJSONObject(decoder *json.Decoder, section []string, line func(int64) int) ([]configEntry, error) {
+	entries := []configEntry{}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+
+		if nil != err {
+			return nil, err
+		}
+
+		key := token.(string)
+		at := line(decoder.InputOffset())
+
+		if token, err = decoder.Token(); nil != err {
+			return nil, err
+		} else if token == json.Delim('{') {
+			nested := append(append([]string{}, section...), key)
+			entries = append(entries, configEntry{nested, "", "", at})
+
+			children, err := parseJSONObject(decoder, nested, line)
+
+			if nil != err {
+				return nil, err
+			}
+
+			entries = append(entries, children...)
+		} else if token == json.Delim('[') {
+			for decoder.More() {
+				if token, err = decoder.Token(); nil != err {
+					return nil, err
+				}
+
+				value, err := formatJSONScalar(token)
+
+				if nil != err {
+					return nil, &ConfigError{"", line(decoder.InputOffset()), err}
+				}
+
+				entries = append(entries, configEntry{section, key, value, at})
+			}
+
+			if _, err = decoder.Token(); nil != err {
+				return nil, err
+			}
+		} else if nil != token {
+			value, err := formatJSONScalar(token)
+
+			if nil != err {
+				return nil, &ConfigError{"", at, err}
+			}
+
+			entries = append(entries, configEntry{section, key, value, at})
+		}
+	}
+
+	_, err := decoder.Token()
+
+	return entries, err
+}
+
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+
+	if nil == err && token != delim {
+		err = fmt.Errorf("Expected '%s' instead of '%v'", delim, token)
+	}
+
+	return err
+}
+
+// Convert a JSON string, number or boolean to a flag value.
+func formatJSONScalar(token json.Token) (string, error) {
+	switch value := token.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+
+	return "", fmt.Errorf("Unexpected '%v' instead of a value", token)
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"assert"
+)
+
+type configTestUnit struct {
+	parser *Parser
+	test   *Command
+	jobs   *int
+	level  *int
+	mode   *string
+	force  *bool
+	depth  *int
+	remote *string
+}
+
+func newConfigTestUnit(t *testing.T, name string, content string) (*configTestUnit, string) {
+	file := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(file, []byte(content), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	unit := &configTestUnit{}
+	unit.parser = NewParser("testing", false)
+	unit.parser.Environment(newEnvironment(map[string]string{"LEVEL": "3"}))
+	unit.parser.Flag("config", "test flag").Value("FILE", true).File("")
+	unit.parser.ConfigFlag("config")
+
+	unit.test = unit.parser.Command("test", "test command")
+	nested := unit.test.Command("nested", "test command")
+	remote := unit.parser.Command("remote", "test command")
+
+	unit.jobs = unit.parser.Flag("jobs", "test flag").Int(1)
+	unit.level = unit.parser.Flag("level", "test flag").EnvironmentValue("LEVEL").Int(1)
+	unit.mode = unit.test.Flag("mode", "test flag").Enum("fast", "fast", "safe")
+	unit.force = unit.test.Flag("force", "test flag").Bool(false)
+	unit.depth = nested.Flag("depth", "test flag").Int(0)
+	unit.remote = remote.Flag("name", "test flag").Enum("origin", "origin", "upstream")
+
+	return unit, file
+}
+
+const iniTestConfig = `# comment
+jobs = 4
+level = 5
+
+; comment
+[test]
+mode = "safe"
+force
+
+[test.nested]
+depth = 2
+
+[remote]
+name = upstream
+`
+
+const jsonTestConfig = `{
+	"jobs": 4,
+	"level": 5,
+	"test": {
+		"mode": "safe",
+		"force": true,
+		"nested": {
+			"depth": [1, 2]
+		}
+	},
+	"remote": {"name": "upstream"}
+}`
+
+func TestConfig(t *testing.T) {
+	for _, format := range []struct{ name, content string }{{"app.ini", iniTestConfig}, {"app.json", jsonTestConfig}} {
+		unit, file := newConfigTestUnit(t, format.name, format.content)
+
+		err := unit.parser.ParseArgs([]string{"-config=" + file, "test", "nested", "-jobs=8"})
+
+		assert.True(t, format.name, nil == err)
+		assert.Equals(t, "command-line", *unit.jobs, 8)
+		assert.Equals(t, "environment", *unit.level, 3)
+		assert.Equals(t, "section", *unit.mode, "safe")
+		assert.True(t, "bool", *unit.force)
+		assert.Equals(t, "nested section", *unit.depth, 2)
+		assert.Equals(t, "inactive section", *unit.remote, "origin")
+	}
+}
+
+func TestConfigDefault(t *testing.T) {
+	unit, file := newConfigTestUnit(t, "app.ini", "jobs = 4\n")
+	required := unit.test.Flag("required", "test flag").Required().Int(0)
+
+	unit.parser.flags["config"].File(file)
+
+	err := unit.parser.ParseArgs([]string{"test"})
+
+	assert.True(t, "error", errors.Is(err, ErrMissingRequired))
+	assert.Equals(t, "default file", *unit.jobs, 4)
+
+	unit.parser.flags["config"].File(file + ".missing")
+
+	assert.True(t, "missing default", nil == unit.parser.ParseArgs([]string{}))
+
+	err = unit.parser.ParseArgs([]string{"test", "-config=" + file + ".missing"})
+
+	assert.True(t, "missing explicit", nil != err)
+
+	os.WriteFile(file, []byte("[test]\nrequired = 1\n"), 0644)
+	unit.parser.flags["config"].File(file)
+
+	assert.True(t, "required", nil == unit.parser.ParseArgs([]string{"test"}))
+	assert.Equals(t, "required value", *required, 1)
+}
+
+func TestConfigErrors(t *testing.T) {
+	var tests = []struct {
+		name     string
+		content  string
+		target   error
+		expected string
+	}{
+		{"app.ini", "jbos = 4\n", ErrUnknownFlag, "%s:1: No such flag 'jbos'. Did you mean 'jobs'?"},
+		{"app.ini", "\n[tset]\nmode = safe\n", ErrUnknownCommand, "%s:2: No such command 'tset'. Did you mean 'test'?"},
+		{"app.ini", "[test]\nmode = slow\n", ErrInvalidValue, "%s:2: Invalid value for flag 'mode': 'slow' is not one of fast, safe"},
+		{"app.ini", "[test\n", ErrInvalidConfig, "%s:1: Unterminated section '[test'"},
+		{"app.json", "{\n\"test\": {\n\"nested\": {\"dpeth\": 1}}}", ErrUnknownFlag, "%s:3: No such flag 'dpeth'. Did you mean 'depth'?"},
+		{"app.json", "{\n\"jobs\": 4,\n}", ErrInvalidConfig, "%s:2: invalid character ',' looking for beginning of value"},
+		{"app.json", "[]", ErrInvalidConfig, "%s:1: Expected '{' instead of '['"},
+	}
+
+	for _, test := range tests {
+		var config *ConfigError
+
+		unit, file := newConfigTestUnit(t, test.name, test.content)
+		err := unit.parser.ParseArgs([]string{"-config=" + file, "test"})
+
+		assert.True(t, test.expected, errors.Is(err, test.target))
+		assert.True(t, "errors.As", errors.As(err, &config))
+		assert.Equals(t, "file", config.File, file)
+		assert.Equals(t, "message", err.Error(), fmt.Sprintf(test.expected, file))
+	}
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+)
+
+// The state passed to the action of a command (see Command.Action).
+type Context struct {
+	ctx    context.Context
+	parser *Parser
+	cmd    *Command
+}
+
+// Returns the context passed to Parser.RunContext. It is cancelled
+// on interrupts if the parser has been configured accordingly (see
+// Parser.CancelOnSignal).
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
+// Returns the parser running the action.
+func (c *Context) Parser() *Parser {
+	return c.parser
+}
+
+// Returns the triggered command or nil if the default action of the
+// parser is run without a command.
+func (c *Context) Command() *Command {
+	return c.cmd
+}
+
+// Returns the number of arguments assigned to the triggered command.
+func (c *Context) NArg() int {
+	return len(c.Args())
+}
+
+// Returns the arguments assigned to the triggered command. The
+// arguments following the flag terminator are available through
+// the parser (see Parser.Args).
+func (c *Context) Args() []string {
+	if nil == c.cmd {
+		return []string{}
+	}
+
+	return c.cmd.args
+}
+
+// Find a flag visible to the triggered command along with its
+// current value (see Parser.Lookup).
+func (c *Context) Lookup(name string) (*Flag, interface{}) {
+	return c.parser.Lookup(name)
+}
+
+// Returns the current value of a flag visible to the triggered
+// command or nil if there is no such flag.
+func (c *Context) Value(name string) interface{} {
+	_, value := c.parser.Lookup(name)
+
+	return value
+}
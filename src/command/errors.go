@@ -0,0 +1,242 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// Sentinel errors matching the typed parse errors via errors.Is
+	ErrUnknownFlag     = errors.New("unknown flag")
+	ErrUnknownCommand  = errors.New("unknown command")
+	ErrInvalidValue    = errors.New("invalid flag value")
+	ErrMissingValue    = errors.New("missing flag value")
+	ErrMissingRequired = errors.New("missing required flag")
+	ErrValuePanic      = errors.New("flag value panicked")
+	ErrMissingAction   = errors.New("missing action")
+	ErrInvalidConfig   = errors.New("invalid configuration")
+)
+
+// A flag which is not visible in the current parsing context.
+type UnknownFlagError struct {
+	// Name of the flag without prefix or value
+	Name string
+	// The command-line argument containing the flag
+	Input string
+	// Position of the argument on the command-line
+	Index int
+	// Names of similar flags in the same context
+	Suggestions []string
+}
+
+// An argument which is neither a flag nor a registered command.
+type UnknownCommandError struct {
+	// Name of the command
+	Name string
+	// The command-line argument
+	Input string
+	// Position of the argument on the command-line
+	Index int
+	// Names of similar commands
+	Suggestions []string
+}
+
+// A value rejected by the flag value (see Value.Set).
+type InvalidValueError struct {
+	// Name of the flag without prefix
+	Name string
+	// The command-line argument containing the flag
+	Input string
+	// Position of the argument on the command-line. It is -1 if
+	// the value has been read from the environment.
+	Index int
+	// The rejected value
+	Value string
+	// Environment variable the value has been read from (if any)
+	Env string
+	// The error returned by the flag value
+	Err error
+}
+
+// A flag requiring a value (see Flag.Value) without one.
+type MissingValueError struct {
+	// Name of the flag without prefix
+	Name string
+	// The command-line argument containing the flag
+	Input string
+	// Position of the argument on the command-line
+	Index int
+	// Name of the expected value
+	ValueName string
+
+	prefix string
+}
+
+// Required flags (see Flag.Required) which have been provided
+// neither on the command-line nor through the environment.
+type MissingRequiredError struct {
+	// Names of the flags including prefix
+	Names []string
+}
+
+// A panic raised by a flag value (see Value.Set). Parsing is
+// stopped immediately after such a panic.
+type ValuePanicError struct {
+	// Name of the flag without prefix
+	Name string
+	// The value passed to the flag value
+	Value string
+	// The value passed to panic
+	Reason interface{}
+	// The stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// A successful parsing process without an action to run (see
+// Parser.Run), e.g. if no command has been given.
+type MissingActionError struct {
+	// Name of the triggered command. It is empty if no command has
+	// been triggered.
+	Name string
+}
+
+// An invalid configuration file (see Parser.ConfigFlag). Unknown
+// keys and sections are reported as UnknownFlagError and
+// UnknownCommandError respectively, rejected values as
+// InvalidValueError.
+type ConfigError struct {
+	// Path of the configuration file
+	File string
+	// Line of the offending entry. It is 0 if the file could not
+	// be read.
+	Line int
+	// The underlying error
+	Err error
+}
+
+// All errors encountered during a parsing process in order of
+// their occurrence (see Parser.CollectErrors).
+type ParseErrors []error
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for index, err := range e {
+		messages[index] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Expose the members to errors.Is and errors.As.
+func (e ParseErrors) Unwrap() []error {
+	return e
+}
+
+func (e *ValuePanicError) Error() string {
+	return fmt.Sprintf("Flag '%s' panicked on value '%s': %v", e.Name, e.Value, e.Reason)
+}
+
+func (e *ValuePanicError) Is(target error) bool {
+	return target == ErrValuePanic
+}
+
+// Returns the panic reason if it is an error.
+func (e *ValuePanicError) Unwrap() error {
+	if err, ok := e.Reason.(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+func (e *UnknownFlagError) Error() string {
+	return fmt.Sprintf("No such flag '%s'", e.Name) + formatSuggestions(e.Suggestions)
+}
+
+func (e *UnknownFlagError) Is(target error) bool {
+	return target == ErrUnknownFlag
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("No such command '%s'", e.Name) + formatSuggestions(e.Suggestions)
+}
+
+func (e *UnknownCommandError) Is(target error) bool {
+	return target == ErrUnknownCommand
+}
+
+// The message of the underlying error is used if it is not empty.
+func (e *InvalidValueError) Error() string {
+	if nil != e.Err && len(e.Err.Error()) > 0 {
+		return e.origin() + ": " + e.Err.Error()
+	} else if len(e.Env) > 0 {
+		return fmt.Sprintf("Unable to write value '%s' from '%s' to flag '%s'", e.Value, e.Env, e.Name)
+	}
+
+	return fmt.Sprintf("Unable to write value '%s' to flag '%s'", e.Value, e.Name)
+}
+
+// Name the flag and where its value was found.
+func (e *InvalidValueError) origin() string {
+	if len(e.Env) > 0 {
+		return fmt.Sprintf("Invalid value for flag '%s' from '%s'", e.Name, e.Env)
+	} else if e.Index >= 0 && len(e.Input) > 0 {
+		return fmt.Sprintf("Invalid value for flag '%s' in '%s'", e.Name, e.Input)
+	}
+
+	return fmt.Sprintf("Invalid value for flag '%s'", e.Name)
+}
+
+func (e *InvalidValueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MissingValueError) Error() string {
+	return fmt.Sprintf("Flag %s%s requires a value %s", e.prefix, e.Name, e.ValueName)
+}
+
+func (e *MissingValueError) Is(target error) bool {
+	return target == ErrMissingValue
+}
+
+func (e *MissingRequiredError) Error() string {
+	return fmt.Sprintf("Missing required flag(s) %s", strings.Join(e.Names, ", "))
+}
+
+func (e *MissingRequiredError) Is(target error) bool {
+	return target == ErrMissingRequired
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.File, e.Err)
+}
+
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MissingActionError) Error() string {
+	if len(e.Name) == 0 {
+		return "Missing command"
+	}
+
+	return fmt.Sprintf("Missing action for command '%s'", e.Name)
+}
+
+func (e *MissingActionError) Is(target error) bool {
+	return target == ErrMissingAction
+}
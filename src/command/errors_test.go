@@ -0,0 +1,195 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"assert"
+)
+
+func TestUnknownFlagError(t *testing.T) {
+	var target *UnknownFlagError
+	unit, _ := newErrorTestUnit()
+	err := unit.ParseArgs([]string{"cmd1", "-verbos=1"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrUnknownFlag))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "verbos")
+	assert.Equals(t, "input", target.Input, "-verbos=1")
+	assert.Equals(t, "index", target.Index, 1)
+}
+
+func TestUnknownCommandError(t *testing.T) {
+	var target *UnknownCommandError
+	unit, _ := newErrorTestUnit()
+	err := unit.ParseArgs([]string{"-verbose", "tset"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrUnknownCommand))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "tset")
+	assert.Equals(t, "index", target.Index, 1)
+}
+
+func TestInvalidValueError(t *testing.T) {
+	var target *InvalidValueError
+	unit, _ := newErrorTestUnit()
+	err := unit.ParseArgs([]string{"cmd1", "-jobs", "many"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrInvalidValue))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "jobs")
+	assert.Equals(t, "input", target.Input, "-jobs")
+	assert.Equals(t, "index", target.Index, 1)
+	assert.Equals(t, "value", target.Value, "many")
+	assert.True(t, "cause", nil != errors.Unwrap(err))
+}
+
+func TestInvalidValueErrorEnvironment(t *testing.T) {
+	var target *InvalidValueError
+	unit, _ := newErrorTestUnit()
+
+	unit.Environment(newEnvironment(map[string]string{"VERBOSE": "maybe"}))
+
+	err := unit.ParseArgs([]string{})
+
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "index", target.Index, -1)
+	assert.Equals(t, "env", target.Env, "VERBOSE")
+	assert.True(t, "message", strings.HasPrefix(target.Error(), "Invalid value for flag 'verbose' from 'VERBOSE': "))
+}
+
+func TestMissingValueError(t *testing.T) {
+	var target *MissingValueError
+	unit, _ := newErrorTestUnit()
+	err := unit.ParseArgs([]string{"cmd1", "-jobs"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrMissingValue))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "jobs")
+	assert.Equals(t, "value name", target.ValueName, "NUM")
+	assert.Equals(t, "message", err.Error(), "Flag -jobs requires a value NUM")
+}
+
+func TestMissingRequiredError(t *testing.T) {
+	var target *MissingRequiredError
+	unit, cmd := newErrorTestUnit()
+
+	cmd.Flag("output", "test flag").Required().Bool(false)
+
+	err := unit.ParseArgs([]string{"cmd1"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrMissingRequired))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.StringArrayEquals(t, "names", target.Names, []string{"-output"})
+}
+
+func TestMissingActionError(t *testing.T) {
+	var target *MissingActionError
+	unit, _ := newErrorTestUnit()
+
+	err := unit.Run([]string{"cmd1"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrMissingAction))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "cmd1")
+	assert.Equals(t, "message", err.Error(), "Missing action for command 'cmd1'")
+}
+
+func TestParseErrors(t *testing.T) {
+	var unknown *UnknownFlagError
+	var invalid *InvalidValueError
+	var out bytes.Buffer
+	unit, _ := newErrorTestUnit()
+
+	unit.CollectErrors(true)
+
+	err := unit.ParseArgs([]string{"-verbos", "cmd1", "-jobs=many", "-fail", "-jobs"})
+	errs, ok := err.(ParseErrors)
+
+	assert.True(t, "aggregate", ok)
+	assert.Equals(t, "error count", len(errs), 4)
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrMissingValue))
+	assert.True(t, "errors.As unknown", errors.As(err, &unknown))
+	assert.True(t, "errors.As invalid", errors.As(err, &invalid))
+
+	assert.Equals(t, "first", unknown.Name, "verbos")
+	assert.Equals(t, "second", invalid.Value, "many")
+
+	unit.WriteError(&out, err.Error())
+
+	lines := strings.Split(out.String(), "\n")
+
+	assert.StringArrayEquals(t, "messages", lines[:5], []string{
+		"testing: No such flag 'verbos'. Did you mean 'verbose'?",
+		"testing: Invalid value for flag 'jobs' in '-jobs=many': 'many' is not a valid integer value.",
+		"testing: No such flag 'fail'",
+		"testing: Flag -jobs requires a value NUM",
+		"Usage: testing [FLAG]... [COMMAND] [FLAG]...",
+	})
+}
+
+func TestValuePanicError(t *testing.T) {
+	var target *ValuePanicError
+	var verbose bool
+	reason := errors.New("boom")
+	unit, _ := newErrorTestUnit()
+
+	unit.Flag("panic", "test flag").Var(&panicTestValue{reason})
+	unit.CollectErrors(true)
+
+	err := unit.ParseArgs([]string{"-panic=now", "-verbose", "-unknown"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrValuePanic))
+	assert.True(t, "errors.Is reason", errors.Is(err, reason))
+	assert.True(t, "errors.As", errors.As(err, &target))
+
+	assert.Equals(t, "name", target.Name, "panic")
+	assert.Equals(t, "value", target.Value, "now")
+	assert.True(t, "stack", len(target.Stack) > 0)
+
+	_, value := unit.Lookup("verbose")
+	verbose = value.(bool)
+
+	assert.False(t, "parsing continued after panic", verbose)
+}
+
+func TestStrictParsing(t *testing.T) {
+	unit, _ := newErrorTestUnit()
+	err := unit.ParseArgs([]string{"-unknown", "-verbose"})
+
+	assert.True(t, "errors.Is", errors.Is(err, ErrUnknownFlag))
+
+	_, value := unit.Lookup("verbose")
+
+	assert.Equals(t, "flag after error", value, false)
+}
+
+type panicTestValue struct {
+	reason error
+}
+
+func (p *panicTestValue) Set(value string) error {
+	panic(p.reason)
+}
+
+func newErrorTestUnit() (*Parser, *Command) {
+	unit := NewParser("testing", false)
+	cmd := unit.Command("cmd1", "test command 1")
+
+	unit.Flag("verbose", "test flag").EnvironmentValue("VERBOSE").Bool(false)
+	cmd.Flag("jobs", "test flag").Value("NUM", true).Int(1)
+
+	unit.Environment(newEnvironment(map[string]string{}))
+
+	return unit, cmd
+}
@@ -1,50 +1,75 @@
 package command
 
-import (
-	"errors"
-)
-
 type errorTracker struct {
-	throw bool
-	fifo  bool
-	msg   string
+	halt    bool
+	fifo    bool
+	collect bool
+	abort   bool
+	errs    []error
 }
 
-// Create an error instance from the logs.
+// Returns the tracked error. A collecting tracker returns all
+// errors as ParseErrors.
 // If no error has been logged yet, nil is returned.
 func (e *errorTracker) Error() error {
-	if len(e.msg) > 0 {
-		return errors.New(e.msg)
+	if len(e.errs) == 0 {
+		return nil
+	} else if e.collect {
+		return ParseErrors(e.errs)
 	}
 
-	return nil
+	return e.errs[0]
 }
 
 // Check if the tracker instance can hold any more errors.
 // If the tracker is configured to drop old errors in favor
-// of new ones, this method always returns false.
+// of new ones or to collect all errors, this method always
+// returns false.
 func (e *errorTracker) Saturated() bool {
 	// FIFO is saturated on first entry
 	// LIFO is never saturated
-	return e.fifo && len(e.msg) > 0
+	return e.fifo && false == e.collect && len(e.errs) > 0
+}
+
+// Check if processing should stop. This is the case after the
+// first error if the tracker is configured to halt on errors or
+// after an error has been stored using Abort.
+func (e *errorTracker) Halted() bool {
+	return e.abort || (e.halt && len(e.errs) > 0)
 }
 
-// Store the given error message is not empty. If the tracker
-// is configured to panic upon receiving errors, this method
-// panics if the provided error is not an empty string.
-func (e *errorTracker) Store(err string) {
-	if len(err) > 0 {
-		if false == e.Saturated() {
-			e.msg = err
+// Store the given error if it is not nil.
+func (e *errorTracker) Store(err error) {
+	if nil != err {
+		if e.collect {
+			e.errs = append(e.errs, err)
+		} else if false == e.Saturated() {
+			e.errs = []error{err}
 		}
+	}
+}
 
-		if e.throw {
-			panic(err)
+// Store the given error and halt processing regardless of the
+// configuration. The error takes precedence over stored errors
+// unless all errors are collected.
+func (e *errorTracker) Abort(err error) {
+	if nil != err {
+		if e.collect {
+			e.errs = append(e.errs, err)
+		} else {
+			e.errs = []error{err}
 		}
+
+		e.abort = true
 	}
 }
 
 // Factory method for error trackers.
-func newErrorTracker(panicOnError bool, storeFirstError bool) *errorTracker {
-	return &errorTracker{panicOnError, storeFirstError, ""}
+func newErrorTracker(haltOnError bool, storeFirstError bool) *errorTracker {
+	return &errorTracker{haltOnError, storeFirstError, false, false, nil}
+}
+
+// Factory method for error trackers keeping all errors.
+func newErrorCollector() *errorTracker {
+	return &errorTracker{false, false, true, false, nil}
 }
@@ -1,13 +1,14 @@
 package command
 
 import (
+	"errors"
 	"testing"
 )
 
 func TestFIFO(t *testing.T) {
 	e := newErrorTracker(false, true)
-	first := "first"
-	second := "second"
+	first := errors.New("first")
+	second := errors.New("second")
 
 	assertSaturation(false, e, t)
 
@@ -23,8 +24,8 @@ func TestFIFO(t *testing.T) {
 
 func TestLIFO(t *testing.T) {
 	e := newErrorTracker(false, false)
-	first := "first"
-	second := "second"
+	first := errors.New("first")
+	second := errors.New("second")
 
 	assertSaturation(false, e, t)
 
@@ -38,25 +39,65 @@ func TestLIFO(t *testing.T) {
 	assertError(second, e, t)
 }
 
-func TestPanic(t *testing.T) {
+func TestCollect(t *testing.T) {
+	e := newErrorCollector()
+	first := errors.New("first")
+	second := errors.New("second")
+
+	e.Store(first)
+	e.Store(nil)
+	e.Store(second)
+
+	assertSaturation(false, e, t)
+
+	if errs, ok := e.Error().(ParseErrors); false == ok {
+		t.Error("collector yielded no aggregate error")
+	} else if len(errs) != 2 || errs[0] != first || errs[1] != second {
+		t.Errorf("unexpected collected errors '%v'", errs)
+	}
+}
+
+func TestHalt(t *testing.T) {
 	e := newErrorTracker(true, true)
-	m := "bail"
+	m := errors.New("bail")
 
-	defer func(assertion string) {
-		if r := recover(); nil == r {
-			t.Error("expected panic did not occur")
-		} else if assertion != r {
-			t.Errorf("expected panic reason '%s' but got '%s'", assertion, r)
-		}
-	}(m)
+	if e.Halted() {
+		t.Error("empty tracker requested to halt")
+	}
 
 	e.Store(m)
+
+	if false == e.Halted() {
+		t.Error("tracker did not request to halt")
+	}
+
+	assertError(m, e, t)
+}
+
+func TestAbort(t *testing.T) {
+	e := newErrorTracker(false, true)
+	first := errors.New("first")
+	fatal := errors.New("fatal")
+
+	e.Store(first)
+
+	if e.Halted() {
+		t.Error("lenient tracker requested to halt")
+	}
+
+	e.Abort(fatal)
+
+	if false == e.Halted() {
+		t.Error("tracker did not request to halt after abort")
+	}
+
+	assertError(fatal, e, t)
 }
 
 func TestNoOp(t *testing.T) {
 	e := newErrorTracker(false, false)
 
-	e.Store("")
+	e.Store(nil)
 
 	if err := e.Error(); nil != err {
 		t.Error("tracker yielded an error")
@@ -65,14 +106,14 @@ func TestNoOp(t *testing.T) {
 
 func assertSaturation(assertion bool, unit *errorTracker, t *testing.T) {
 	if assertion != unit.Saturated() {
-		t.Error("unexpected tracker saturation state: %v", assertion)
+		t.Errorf("unexpected tracker saturation state: %v", assertion)
 	}
 }
 
-func assertError(assertion string, unit *errorTracker, t *testing.T) {
+func assertError(assertion error, unit *errorTracker, t *testing.T) {
 	if err := unit.Error(); nil == err {
 		t.Error("no error was stored")
-	} else if assertion != err.Error() {
-		t.Error("expected tracker error '%s' but got '%s'", assertion, err.Error())
+	} else if assertion != err {
+		t.Errorf("expected tracker error '%v' but got '%v'", assertion, err)
 	}
 }
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// Exit codes used by Parser.Exit
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+var (
+	// errors caused by invalid command-line input
+	usageErrors = []error{
+		ErrUnknownFlag,
+		ErrUnknownCommand,
+		ErrInvalidValue,
+		ErrMissingValue,
+		ErrMissingRequired,
+		ErrMissingAction,
+	}
+)
+
+// An error providing the exit code of the process (see ExitCode).
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// An error terminating the process with a custom exit code. Without
+// an underlying error, the process terminates silently (see
+// Parser.Exit).
+type ExitError struct {
+	// The exit code of the process
+	Code int
+	// The reason for the termination (if any)
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	if nil != e.Err {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("Exit status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Map an error returned by RunContext to an exit code following the
+// conventions of the flag package: ExitSuccess without an error, the
+// code provided by an ExitCoder in the error chain, ExitUsage for
+// parse errors of the command-line and ExitFailure for any other
+// error, including invalid configuration files.
+func ExitCode(err error) int {
+	var coder ExitCoder
+
+	if nil == err {
+		return ExitSuccess
+	} else if errors.As(err, &coder) {
+		return coder.ExitCode()
+	} else if isUsageError(err) {
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+// Replace the function terminating the process (os.Exit by default),
+// e.g. to capture the exit code in tests.
+func (p *Parser) ExitHandler(exit func(code int)) {
+	p.exit = exit
+}
+
+// Terminate the process with the exit code of the error (see
+// ExitCode). Usage errors are written to stderr along with the usage
+// message, other errors without it.
+func (p *Parser) Exit(err error) {
+	code := ExitCode(err)
+
+	if nil == err {
+		// success
+	} else if code == ExitUsage {
+		p.PrintError(err.Error())
+	} else if exit, ok := err.(*ExitError); false == ok || nil != exit.Err {
+		p.writeMessage(os.Stderr, err.Error())
+	}
+
+	p.exit(code)
+}
+
+// Run the application (see Run) and terminate the process with the
+// resulting exit code (see Exit).
+func (p *Parser) Main(argv []string) {
+	p.Exit(p.Run(argv))
+}
+
+// Whether the error was caused by the command-line. Errors of the
+// configuration file are not, even if they wrap a usage error.
+func isUsageError(err error) bool {
+	var errs ParseErrors
+
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if isUsageError(e) {
+				return true
+			}
+		}
+
+		return false
+	} else if errors.Is(err, ErrInvalidConfig) {
+		return false
+	}
+
+	for _, target := range usageErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"assert"
+)
+
+type exitTestError int
+
+func (e exitTestError) Error() string {
+	return fmt.Sprint("exit ", int(e))
+}
+
+func (e exitTestError) ExitCode() int {
+	return int(e)
+}
+
+func TestExitCode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil", nil, ExitSuccess},
+		{"error", errors.New("failed"), ExitFailure},
+		{"coder", exitTestError(3), 3},
+		{"wrapped coder", fmt.Errorf("wrapped: %w", exitTestError(4)), 4},
+		{"exit error", &ExitError{5, errors.New("failed")}, 5},
+		{"unknown flag", &UnknownFlagError{"x", "-x", 0, nil}, ExitUsage},
+		{"missing action", &MissingActionError{""}, ExitUsage},
+		{"collected", ParseErrors{&MissingRequiredError{[]string{"-x"}}}, ExitUsage},
+		{"panic", &ValuePanicError{"x", "", "reason", nil}, ExitFailure},
+		{"config", &ConfigError{"app.rc", 2, &UnknownFlagError{"x", "x", -1, nil}}, ExitFailure},
+		{"collected config", ParseErrors{&ConfigError{"app.rc", 2, &UnknownFlagError{"x", "x", -1, nil}}}, ExitFailure},
+		{"collected mixed", ParseErrors{&ConfigError{"app.rc", 0, errors.New("x")}, &UnknownCommandError{"x", "x", 0, nil}}, ExitUsage},
+	}
+
+	for _, test := range tests {
+		assert.Equals(t, test.name, ExitCode(test.err), test.expected)
+	}
+}
+
+func TestExitError(t *testing.T) {
+	failure := errors.New("failed")
+
+	assert.Equals(t, "message", (&ExitError{3, failure}).Error(), "failed")
+	assert.Equals(t, "silent message", (&ExitError{3, nil}).Error(), "Exit status 3")
+	assert.True(t, "errors.Is", errors.Is(&ExitError{3, failure}, failure))
+}
+
+func TestParserMain(t *testing.T) {
+	var tests = []struct {
+		argv     []string
+		action   error
+		code     int
+		expected string
+	}{
+		{[]string{}, nil, ExitSuccess, ""},
+		{[]string{"-help"}, errors.New("not run"), ExitSuccess, ""},
+		{[]string{"-unknown"}, nil, ExitUsage, "testing: No such flag 'unknown'\nUsage: testing "},
+		{[]string{}, errors.New("failed"), ExitFailure, "testing: failed"},
+		{[]string{}, &ExitError{3, errors.New("failed")}, 3, "testing: failed"},
+		{[]string{}, &ExitError{4, nil}, 4, ""},
+	}
+
+	defer silenceStdout()()
+
+	for _, test := range tests {
+		code := -1
+		unit := NewParser("testing", false)
+		action := test.action
+
+		unit.ExitHandler(func(status int) {
+			code = status
+		})
+		unit.Action(func(ctx *Context) error {
+			return action
+		})
+
+		output := captureStderr(func() {
+			unit.Main(test.argv)
+		})
+
+		assert.Equals(t, "code", code, test.code)
+
+		if test.code == ExitUsage {
+			assert.True(t, "usage", strings.HasPrefix(output, test.expected))
+		} else {
+			assert.Equals(t, "output", output, test.expected)
+		}
+	}
+}
+
+// Returns the output written to stderr by the function.
+func captureStderr(fn func()) string {
+	stderr := os.Stderr
+	reader, writer, _ := os.Pipe()
+	os.Stderr = writer
+
+	fn()
+
+	os.Stderr = stderr
+	writer.Close()
+
+	output, _ := io.ReadAll(reader)
+	reader.Close()
+
+	return strings.TrimSuffix(string(output), "\n")
+}
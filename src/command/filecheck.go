@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Constraints applied to the paths assigned to file and directory
+// flags. The constraints can be combined.
+type FileCheck int
+
+const (
+	// The path must exist.
+	FileExists FileCheck = 1 << iota
+	// The path must not exist yet.
+	FileNotExists
+	// The directory containing the path must exist.
+	FileParentExists
+	// The path must exist and be readable by the current user.
+	FileReadable
+	// The path must be writable by the current user. For paths which
+	// do not exist yet, the parent directory has to be writable.
+	FileWritable
+	// The path must exist and be executable (or searchable for
+	// directories) by the current user.
+	FileExecutable
+)
+
+const (
+	// access(2) modes
+	accessExecute uint32 = 1 << iota
+	accessWrite
+	accessRead
+)
+
+// Validate an absolute path against the given constraints. Paths
+// which exist must match the expected type (file or directory).
+func checkFile(path string, dir bool, checks FileCheck) error {
+	info, err := os.Stat(path)
+
+	if nil != err && false == os.IsNotExist(err) {
+		return err
+	} else if nil != err {
+		if checks&(FileExists|FileReadable|FileExecutable) != 0 {
+			return fmt.Errorf("'%s' does not exist", path)
+		}
+
+		return checkMissingFile(path, checks)
+	} else if checks&FileNotExists != 0 {
+		return fmt.Errorf("'%s' already exists", path)
+	} else if info.IsDir() && false == dir {
+		return fmt.Errorf("'%s' is a directory", path)
+	} else if false == info.IsDir() && dir {
+		return fmt.Errorf("'%s' is not a directory", path)
+	}
+
+	if checks&FileReadable != 0 && nil != checkAccess(path, accessRead) {
+		return fmt.Errorf("'%s' is not readable", path)
+	} else if checks&FileWritable != 0 && nil != checkAccess(path, accessWrite) {
+		return fmt.Errorf("'%s' is not writable", path)
+	} else if checks&FileExecutable != 0 && nil != checkAccess(path, accessExecute) {
+		return fmt.Errorf("'%s' is not executable", path)
+	}
+
+	return nil
+}
+
+// Validate a path which does not exist. Only the constraints
+// concerning the parent directory are applicable.
+func checkMissingFile(path string, checks FileCheck) error {
+	parent := filepath.Dir(path)
+
+	if checks&(FileParentExists|FileWritable) == 0 {
+		return nil
+	} else if info, err := os.Stat(parent); nil != err || false == info.IsDir() {
+		return fmt.Errorf("parent directory of '%s' does not exist", path)
+	} else if checks&FileWritable != 0 && nil != checkAccess(parent, accessWrite) {
+		return fmt.Errorf("parent directory of '%s' is not writable", path)
+	}
+
+	return nil
+}
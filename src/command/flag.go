@@ -1,84 +1,347 @@
 package command
 
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+)
+
 const (
 	flagValueName = "VAL"
 )
 
 type Flag struct {
-	desc string
-	env  string
+	desc     string
+	group    string
+	env      string
+	envSep   string
+	split    string
+	short    rune
+	required bool
 
 	valueName string
 	valueReq  bool
 	value     Value
+	defValue  string
+	complete  func(string) []string
+
+	// dynamic runtime data
+
+	present bool
+	seen    bool
+	origin  Provenance
 }
 
 func (f *Flag) Bool(defaultValue bool) *bool {
 	out := defaultValue
-	value := &boolValue{&out}
 
-	f.value = value
+	f.BoolVar(&out)
 
 	return &out
 }
 
 func (f *Flag) BoolVar(out *bool) {
-	value := &boolValue{out}
-
-	f.value = value
+	f.setValue(&boolValue{out})
 }
 
 func (f *Flag) Int(defaultValue int) *int {
 	out := defaultValue
-	value := &intValue{&out}
 
-	f.value = value
+	f.IntVar(&out)
 
 	return &out
 }
 
 func (f *Flag) IntVar(out *int) {
-	value := &intValue{out}
+	f.setValue(&intValue{out})
+}
 
-	f.value = value
+func (f *Flag) String(defaultValue string) *string {
+	out := defaultValue
+
+	f.StringVar(&out)
+
+	return &out
+}
+
+func (f *Flag) StringVar(out *string) {
+	f.setValue(&stringValue{out})
 }
 
-func (f *Flag) File(defaultValue string) *string {
+func (f *Flag) Float64(defaultValue float64) *float64 {
 	out := defaultValue
-	value := &fileValue{&out, false}
 
-	f.value = value
+	f.Float64Var(&out)
 
 	return &out
 }
 
-func (f *Flag) FileVar(out *string) {
-	value := &fileValue{out, false}
+func (f *Flag) Float64Var(out *float64) {
+	f.setValue(&float64Value{out})
+}
 
-	f.value = value
+// Accept a 64-bit integer. Besides decimal numbers, the prefixes
+// 0x, 0o and 0b select another base.
+func (f *Flag) Int64(defaultValue int64) *int64 {
+	out := defaultValue
+
+	f.Int64Var(&out)
+
+	return &out
 }
 
-func (f *Flag) Dir(defaultValue string) *string {
+func (f *Flag) Int64Var(out *int64) {
+	f.setValue(&int64Value{out})
+}
+
+// Accept an unsigned 64-bit integer (see Int64).
+func (f *Flag) Uint64(defaultValue uint64) *uint64 {
 	out := defaultValue
-	value := &fileValue{&out, true}
 
-	f.value = value
+	f.Uint64Var(&out)
 
 	return &out
 }
 
-func (f *Flag) DirVar(out *string) {
-	value := &fileValue{out, true}
+func (f *Flag) Uint64Var(out *uint64) {
+	f.setValue(&uint64Value{out})
+}
 
-	f.value = value
+// Accept a duration understood by time.ParseDuration, e.g. 1m30s.
+func (f *Flag) Duration(defaultValue time.Duration) *time.Duration {
+	out := defaultValue
+
+	f.DurationVar(&out)
+
+	return &out
+}
+
+func (f *Flag) DurationVar(out *time.Duration) {
+	f.setValue(&durationValue{out})
+}
+
+// Accept a point in time in the given layout (see time.Parse), e.g.
+// time.DateOnly.
+func (f *Flag) Time(defaultValue time.Time, layout string) *time.Time {
+	out := defaultValue
+
+	f.TimeVar(&out, layout)
+
+	return &out
+}
+
+func (f *Flag) TimeVar(out *time.Time, layout string) {
+	f.setValue(&timeValue{out, layout})
+}
+
+// Accept a number of bytes with an optional decimal (KB, MB, ...) or
+// binary (KiB, MiB, ...) unit, e.g. 10MiB. The unit is case
+// insensitive. Fractions have to amount to whole bytes.
+func (f *Flag) ByteSize(defaultValue uint64) *uint64 {
+	out := defaultValue
+
+	f.ByteSizeVar(&out)
+
+	return &out
+}
+
+func (f *Flag) ByteSizeVar(out *uint64) {
+	f.setValue(&byteSizeValue{out})
+}
+
+// Accept an absolute URL. An invalid default value yields an empty
+// URL.
+func (f *Flag) URL(defaultValue string) *url.URL {
+	out := url.URL{}
+
+	if u, err := url.Parse(defaultValue); nil == err {
+		out = *u
+	}
+
+	f.URLVar(&out)
+
+	return &out
+}
+
+func (f *Flag) URLVar(out *url.URL) {
+	f.setValue(&urlValue{out})
+}
+
+// Accept an IPv4 or IPv6 address.
+func (f *Flag) IP(defaultValue net.IP) *net.IP {
+	out := defaultValue
+
+	f.IPVar(&out)
+
+	return &out
+}
+
+func (f *Flag) IPVar(out *net.IP) {
+	f.setValue(&ipValue{out})
+}
+
+// Accept a network in CIDR notation, e.g. 10.0.0.0/8.
+func (f *Flag) IPNet(defaultValue net.IPNet) *net.IPNet {
+	out := defaultValue
+
+	f.IPNetVar(&out)
+
+	return &out
+}
+
+func (f *Flag) IPNetVar(out *net.IPNet) {
+	f.setValue(&ipNetValue{out})
+}
+
+// Accept a regular expression (see regexp.Compile). The default
+// pattern is not compiled if it is empty; an invalid one panics.
+func (f *Flag) Regexp(defaultPattern string) **regexp.Regexp {
+	var out *regexp.Regexp = nil
+
+	if len(defaultPattern) > 0 {
+		out = regexp.MustCompile(defaultPattern)
+	}
+
+	f.RegexpVar(&out)
+
+	return &out
 }
 
+func (f *Flag) RegexpVar(out **regexp.Regexp) {
+	f.setValue(&regexpValue{out})
+}
+
+// Accept bytes in hexadecimal notation, e.g. cafe01.
+func (f *Flag) Hex(defaultValue []byte) *[]byte {
+	out := defaultValue
+
+	f.HexVar(&out)
+
+	return &out
+}
+
+func (f *Flag) HexVar(out *[]byte) {
+	f.setValue(&hexValue{out})
+}
+
+// Accept bytes in standard base64 encoding. The padding is optional.
+func (f *Flag) Base64(defaultValue []byte) *[]byte {
+	out := defaultValue
+
+	f.Base64Var(&out)
+
+	return &out
+}
+
+func (f *Flag) Base64Var(out *[]byte) {
+	f.setValue(&base64Value{out})
+}
+
+// Collect the values of all occurrences of the flag, e.g.
+// -tag=a -tag=b. The first occurrence replaces the default values.
+func (f *Flag) Strings(defaultValues ...string) *[]string {
+	out := append([]string{}, defaultValues...)
+
+	f.StringsVar(&out)
+
+	return &out
+}
+
+func (f *Flag) StringsVar(out *[]string) {
+	f.setValue(&stringsValue{out, &f.split, append([]string{}, (*out)...), true})
+}
+
+// Collect the integer values of all occurrences of the flag (see
+// Strings).
+func (f *Flag) Ints(defaultValues ...int) *[]int {
+	out := append([]int{}, defaultValues...)
+
+	f.IntsVar(&out)
+
+	return &out
+}
+
+func (f *Flag) IntsVar(out *[]int) {
+	f.setValue(&intsValue{out, &f.split, append([]int{}, (*out)...), true})
+}
+
+// Collect key=value pairs from all occurrences of the flag, e.g.
+// -label=env=prod -label=team=ops. Later occurrences of a key
+// overwrite earlier ones.
+func (f *Flag) StringMap() map[string]string {
+	out := make(map[string]string)
+
+	f.StringMapVar(out)
+
+	return out
+}
+
+// Store the pairs in the given map. Its existing entries serve as
+// default and are removed by the first occurrence of the flag.
+func (f *Flag) StringMapVar(out map[string]string) {
+	defaults := make(map[string]string, len(out))
+
+	for key, val := range out {
+		defaults[key] = val
+	}
+
+	f.setValue(&stringMapValue{out, &f.split, defaults, true})
+}
+
+// Accept one of the given strings as value.
+func (f *Flag) Enum(defaultValue string, choices ...string) *string {
+	out := defaultValue
+
+	f.EnumVar(&out, choices...)
+
+	return &out
+}
+
+func (f *Flag) EnumVar(out *string, choices ...string) {
+	f.setValue(&enumValue{out, choices})
+}
+
+// Accept a path to a file. The path is expanded (see Dir) and
+// stored as absolute path. Without any checks, the file has to exist.
+func (f *Flag) File(defaultValue string, checks ...FileCheck) *string {
+	out := defaultPath(defaultValue)
+
+	f.FileVar(&out, checks...)
+
+	return &out
+}
+
+func (f *Flag) FileVar(out *string, checks ...FileCheck) {
+	f.setValue(&fileValue{out, false, combineChecks(checks)})
+}
+
+// Accept a path to a directory. A leading ~ is replaced by the home
+// directory of the current user and environment variables are
+// expanded. This applies to the default value as well, which is not
+// subject to the checks though. Without any checks, the directory
+// has to exist.
+func (f *Flag) Dir(defaultValue string, checks ...FileCheck) *string {
+	out := defaultPath(defaultValue)
+
+	f.DirVar(&out, checks...)
+
+	return &out
+}
+
+func (f *Flag) DirVar(out *string, checks ...FileCheck) {
+	f.setValue(&fileValue{out, true, combineChecks(checks)})
+}
+
+// Use a custom value implementation. If the value implements the
+// Getter interface, its current state is shown as default value in
+// the usage message.
 func (f *Flag) Var(out Value) {
-	f.value = out
+	f.setValue(out)
 }
 
 func (f *Flag) Void(emulateBool bool) {
-	f.value = &voidValue{emulateBool}
+	f.setValue(&voidValue{emulateBool})
 }
 
 func (f *Flag) EnvironmentValue(name string) *Flag {
@@ -87,6 +350,51 @@ func (f *Flag) EnvironmentValue(name string) *Flag {
 	return f
 }
 
+// Set the separator of the elements of the environment variable of
+// a repeatable flag (see Strings). Defaults to a comma; an empty
+// separator passes the variable as a single value.
+func (f *Flag) EnvironmentSeparator(sep string) *Flag {
+	f.envSep = sep
+
+	return f
+}
+
+// Split each value of a repeatable flag (see Strings) at the given
+// separator, e.g. -tag=a,b is equivalent to -tag=a -tag=b.
+func (f *Flag) Split(sep string) *Flag {
+	f.split = sep
+
+	return f
+}
+
+// Assign a single character name to the flag. It is only recognized
+// by parsers using the GNU notation (see NewGNUParser), e.g. -v.
+func (f *Flag) Short(name rune) *Flag {
+	f.short = name
+
+	return f
+}
+
+// Assign the flag to a group. Flags of the same group share a
+// heading in the usage message.
+func (f *Flag) Group(name string) *Flag {
+	f.group = name
+
+	return f
+}
+
+// Mark the flag as mandatory. It has to be provided either on the
+// command-line or through its environment variable.
+func (f *Flag) Required() *Flag {
+	f.required = true
+
+	return f
+}
+
+// Name the value of the flag. If the value is required, the flag
+// has to be followed by a value on the command-line. Otherwise an
+// omitted value leaves the flag value unchanged, unless it has an
+// AcceptsEmpty method returning true; Set then receives "".
 func (f *Flag) Value(name string, required bool) *Flag {
 	f.valueName = name
 	f.valueReq = required
@@ -94,12 +402,78 @@ func (f *Flag) Value(name string, required bool) *Flag {
 	return f
 }
 
-func (f *Flag) String() string {
+// Provide completion candidates for the value of the flag. The
+// function receives the partial value typed so far. Candidates not
+// starting with it are discarded.
+func (f *Flag) Complete(candidates func(prefix string) []string) *Flag {
+	f.complete = candidates
+
+	return f
+}
+
+// Returns the description of the flag.
+func (f *Flag) Description() string {
 	return f.desc
 }
 
+// Mark the flag as present with a value of the given origin.
+func (f *Flag) mark(origin Provenance) {
+	f.present = true
+	f.origin = origin
+}
+
+// Clear the runtime data of the flag. Repeatable values are restored
+// to their defaults.
+func (f *Flag) clear() {
+	f.present = false
+	f.seen = false
+	f.origin = defaultProvenance()
+
+	if r, ok := f.value.(resettableValue); ok {
+		r.reset()
+	}
+}
+
+// Assign the value and capture its current state as default.
+func (f *Flag) setValue(value Value) {
+	f.value = value
+	f.defValue = ""
+
+	if g, ok := value.(Getter); ok {
+		f.defValue = g.String()
+	}
+}
+
 func newFlag(description string) *Flag {
 	value := &voidValue{}
 
-	return &Flag{description, "", flagValueName, false, value}
+	return &Flag{desc: description,
+		envSep:    listSep,
+		valueName: flagValueName,
+		value:     value,
+		origin:    defaultProvenance()}
+}
+
+func defaultPath(path string) string {
+	if len(path) > 0 {
+		if out, err := expandPath(path); nil == err {
+			return out
+		}
+	}
+
+	return path
+}
+
+func combineChecks(checks []FileCheck) FileCheck {
+	if len(checks) == 0 {
+		return FileExists
+	}
+
+	combined := FileCheck(0)
+
+	for _, check := range checks {
+		combined |= check
+	}
+
+	return combined
 }
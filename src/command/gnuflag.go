@@ -0,0 +1,76 @@
+package command
+
+import (
+	"strings"
+)
+
+const (
+	flagLongPrefix = "--"
+)
+
+// Process a flag in GNU notation, which is either a long flag
+// (--name, --name=value, --name value) or a cluster of single
+// character flags (-v, -abc, -j4, -j 4). The first element of argv
+// is the flag itself; the remaining elements are only inspected if
+// the flag requires a value. The first return value denotes the
+// number of subsequent arguments consumed as flag value.
+func parseGNUFlag(argv []string, index int, long func(string) *Flag, short func(rune) *Flag, visitor flagVisitor) (int, error) {
+	if strings.HasPrefix(argv[0], flagLongPrefix) {
+		return parseLongFlag(argv, index, long, visitor)
+	}
+
+	return parseShortFlags(argv, index, short, visitor)
+}
+
+func parseLongFlag(argv []string, index int, lookup func(string) *Flag, visitor flagVisitor) (int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(argv[0], flagLongPrefix), flagValueSep, 2)
+	key := parts[0]
+	flag := lookup(key)
+
+	if nil == flag {
+		return 0, &UnknownFlagError{key, argv[0], index, nil}
+	} else if len(parts) > 1 {
+		return 0, visitor.assign(key, flag, parts[1], argv[0], index)
+	}
+
+	return parseDetachedValue(argv, index, flagLongPrefix, key, flag, visitor)
+}
+
+func parseShortFlags(argv []string, index int, lookup func(rune) *Flag, visitor flagVisitor) (int, error) {
+	cluster := []rune(strings.TrimPrefix(argv[0], flagPrefix))
+
+	for pos, short := range cluster {
+		key := string(short)
+		flag := lookup(short)
+
+		if nil == flag {
+			return 0, &UnknownFlagError{key, argv[0], index, nil}
+		} else if isBoolFlag(flag.value) {
+			if err := visitor.assign(key, flag, "", argv[0], index); nil != err {
+				return 0, err
+			}
+		} else if rest := cluster[pos+1:]; len(rest) > 0 {
+			// the remainder of the cluster is the value
+			return 0, visitor.assign(key, flag, string(rest), argv[0], index)
+		} else {
+			return parseDetachedValue(argv, index, flagPrefix, key, flag, visitor)
+		}
+	}
+
+	return 0, nil
+}
+
+// Find a flag by its single character name.
+func lookupShort(short rune, flags map[string]*Flag) *Flag {
+	if 0 == short {
+		return nil
+	}
+
+	for _, flag := range flags {
+		if flag.short == short {
+			return flag
+		}
+	}
+
+	return nil
+}
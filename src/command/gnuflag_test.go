@@ -0,0 +1,105 @@
+package command
+
+import (
+	"testing"
+
+	"assert"
+)
+
+func TestParseArgsGNU(t *testing.T) {
+	var verbose bool
+	var all bool
+	var jobs int
+	var level int
+	unit := NewGNUParser("testing", false)
+	cmd := unit.Command("cmd1", "test command 1")
+
+	unit.Flag("verbose", "test flag").Short('v').BoolVar(&verbose)
+	unit.Flag("all", "test flag").Short('a').BoolVar(&all)
+	unit.Flag("level", "test flag").Short('l').Value("LEVEL", false).IntVar(&level)
+	cmd.Flag("jobs", "test flag").Short('j').Value("NUM", true).IntVar(&jobs)
+
+	var tests = []struct {
+		argv    []string
+		verbose bool
+		all     bool
+		jobs    int
+		level   int
+		args    []string
+	}{
+		{[]string{"--verbose", "cmd1"}, true, false, 0, 0, []string{}},
+		{[]string{"cmd1", "--jobs=4", "arg"}, false, false, 4, 0, []string{"arg"}},
+		{[]string{"cmd1", "--jobs", "4", "arg"}, false, false, 4, 0, []string{"arg"}},
+		{[]string{"-va", "cmd1"}, true, true, 0, 0, []string{}},
+		{[]string{"cmd1", "-j4"}, false, false, 4, 0, []string{}},
+		{[]string{"cmd1", "-vaj", "4", "-"}, true, true, 4, 0, []string{"-"}},
+		{[]string{"-l", "cmd1"}, false, false, 0, 0, []string{}},
+		{[]string{"-l2", "cmd1"}, false, false, 0, 2, []string{}},
+		{[]string{"--level=3", "cmd1"}, false, false, 0, 3, []string{}},
+	}
+
+	for _, test := range tests {
+		verbose, all, jobs, level = false, false, 0, 0
+
+		assert.That(t, cmd, newParserMatcher(unit, test.argv))
+
+		assert.Equals(t, "verbose flag", verbose, test.verbose)
+		assert.Equals(t, "all flag", all, test.all)
+		assert.Equals(t, "jobs flag", jobs, test.jobs)
+		assert.Equals(t, "level flag", level, test.level)
+
+		assert.StringArrayEquals(t, "cmd args", cmd.Args(), test.args)
+	}
+}
+
+func TestParseArgsGNUFailure(t *testing.T) {
+	unit := NewGNUParser("testing", false)
+
+	unit.Flag("verbose", "test flag").Short('v').Bool(false)
+	unit.Flag("jobs", "test flag").Short('j').Value("NUM", true).Int(1)
+
+	var failure = [][]string{
+		{"-verbose"},
+		{"--v"},
+		{"-x"},
+		{"--jobs"},
+		{"-j"},
+		{"-vj", "--"},
+	}
+
+	for _, argv := range failure {
+		if err := unit.ParseArgs(argv); nil == err {
+			t.Error("invalid arguments", argv, "caused no error")
+		}
+	}
+
+	err := unit.ParseArgs([]string{"-j"})
+
+	assert.Equals(t, "error", err.Error(), "Flag -j requires a value NUM")
+
+	unit.Flag("config", "test flag").Short('c').Value("FILE", true).Required().Var(&voidValue{})
+
+	err = unit.ParseArgs([]string{"-v"})
+
+	assert.Equals(t, "error", err.Error(), "Missing required flag(s) --config")
+}
+
+func TestFormatGNUFlag(t *testing.T) {
+	var tests = []struct {
+		short    rune
+		req      bool
+		value    Value
+		expected string
+	}{
+		{'j', true, &intValue{}, "-j, --name=VAL"},
+		{'j', false, &intValue{}, "-j, --name[=VAL]"},
+		{'v', false, &boolValue{}, "-v, --name"},
+		{0, true, &intValue{}, "    --name=VAL"},
+	}
+
+	for _, test := range tests {
+		actual := formatGNUFlag("name", test.short, "VAL", test.req, test.value)
+
+		assert.Equals(t, "flag format", actual, test.expected)
+	}
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"errors"
+)
+
+// The function run for a triggered command (see Command.Action).
+type Handler func(ctx *Context) error
+
+// A function wrapping a handler, e.g. to provide logging or tracing
+// for the action of a command.
+type Middleware func(next Handler) Handler
+
+// The hooks registered with the parser or a command.
+type hooks struct {
+	before     []Handler
+	after      []Handler
+	middleware []Middleware
+}
+
+// Run the action of the context with the hooks of the parser and
+// the triggered commands. The Before hooks run from the parser down
+// to the triggered command; if one fails, the remaining ones and
+// the action are skipped. The middleware wraps the action with the
+// outermost layer belonging to the parser. The After hooks always
+// run, from the triggered command up to the parser. All errors are
+// combined.
+func (p *Parser) dispatch(ctx *Context, action Handler) error {
+	chain := []*hooks{&p.hooks}
+
+	if nil != ctx.cmd {
+		for _, cmd := range ctx.cmd.path() {
+			chain = append(chain, &cmd.hooks)
+		}
+	}
+
+	for level := len(chain) - 1; level >= 0; level-- {
+		for index := len(chain[level].middleware) - 1; index >= 0; index-- {
+			action = chain[level].middleware[index](action)
+		}
+	}
+
+	err := runBefore(ctx, chain)
+
+	if nil == err {
+		err = action(ctx)
+	}
+
+	return joinErrors(err, runAfter(ctx, chain))
+}
+
+func runBefore(ctx *Context, chain []*hooks) error {
+	for _, level := range chain {
+		for _, hook := range level.before {
+			if err := hook(ctx); nil != err {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func runAfter(ctx *Context, chain []*hooks) error {
+	errs := []error{}
+
+	for level := len(chain) - 1; level >= 0; level-- {
+		for _, hook := range chain[level].after {
+			errs = append(errs, hook(ctx))
+		}
+	}
+
+	return joinErrors(errs...)
+}
+
+// Combine the errors which are not nil. A single error is returned
+// as is.
+func joinErrors(errs ...error) error {
+	var found []error = nil
+
+	for _, err := range errs {
+		if nil != err {
+			found = append(found, err)
+		}
+	}
+
+	if len(found) == 1 {
+		return found[0]
+	}
+
+	return errors.Join(found...)
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"assert"
+)
+
+func newHandlerTestUnit(trace *[]string) (*Parser, *Command) {
+	record := func(entry string, err error) Handler {
+		return func(ctx *Context) error {
+			*trace = append(*trace, entry)
+
+			return err
+		}
+	}
+	wrap := func(entry string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx *Context) error {
+				*trace = append(*trace, entry+">")
+				err := next(ctx)
+				*trace = append(*trace, "<"+entry)
+
+				return err
+			}
+		}
+	}
+
+	unit := NewParser("testing", false)
+	remote := unit.Command("remote", "test command")
+	add := remote.Command("add", "test command")
+
+	unit.Before(record("app before", nil))
+	unit.After(record("app after", nil))
+	unit.Use(wrap("app1"), wrap("app2"))
+
+	remote.Before(record("remote before", nil)).
+		After(record("remote after", nil)).
+		Use(wrap("remote"))
+
+	add.Before(record("add before", nil)).
+		After(record("add after", nil)).
+		Action(record("add", nil))
+
+	return unit, add
+}
+
+func TestHooks(t *testing.T) {
+	var trace []string
+	unit, _ := newHandlerTestUnit(&trace)
+
+	assert.True(t, "error", nil == unit.Run([]string{"remote", "add"}))
+	assert.StringArrayEquals(t, "trace", trace, []string{
+		"app before", "remote before", "add before",
+		"app1>", "app2>", "remote>", "add", "<remote", "<app2", "<app1",
+		"add after", "remote after", "app after"})
+}
+
+func TestHooksBeforeError(t *testing.T) {
+	var trace []string
+	unit, add := newHandlerTestUnit(&trace)
+	failure := errors.New("before failed")
+
+	unit.Before(func(ctx *Context) error {
+		return failure
+	})
+	add.After(func(ctx *Context) error {
+		return errors.New("after failed")
+	})
+
+	err := unit.Run([]string{"remote", "add"})
+
+	assert.True(t, "before error", errors.Is(err, failure))
+	assert.Equals(t, "message", err.Error(), "before failed\nafter failed")
+	assert.StringArrayEquals(t, "trace", trace, []string{
+		"app before", "add after", "remote after", "app after"})
+}
+
+func TestHooksActionError(t *testing.T) {
+	var trace []string
+	unit, add := newHandlerTestUnit(&trace)
+	failure := errors.New("action failed")
+
+	add.Action(func(ctx *Context) error {
+		return failure
+	})
+
+	err := unit.Run([]string{"remote", "add"})
+
+	assert.Equals(t, "action error", err, failure)
+	assert.StringArrayEquals(t, "after", trace[len(trace)-3:], []string{"add after", "remote after", "app after"})
+}
+
+func TestHooksNotTriggered(t *testing.T) {
+	var trace []string
+	unit, _ := newHandlerTestUnit(&trace)
+
+	unit.Action(func(ctx *Context) error {
+		trace = append(trace, "default")
+
+		return nil
+	})
+
+	assert.True(t, "error", nil == unit.Run([]string{}))
+	assert.StringArrayEquals(t, "trace", trace, []string{
+		"app before", "app1>", "app2>", "default", "<app2", "<app1", "app after"})
+}
@@ -1,9 +1,12 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"runtime/debug"
+	"sort"
 	"strings"
 )
 
@@ -11,18 +14,34 @@ const (
 	flagTermination = "--"
 	flagPrefix      = "-"
 	flagValueSep    = "="
+	helpFlagName    = "help"
+	helpFlagDesc    = "Show the usage message"
+	helpFlagShort   = 'h'
 )
 
 type Parser struct {
 	// static data
 
 	lenient bool
+	collect bool
+	gnu     bool
 	owner   string
+	env     func(string) (string, bool)
+	sorted  bool
+	width   int
+	suggest int
+	signals bool
+	exit    func(int)
+	config  string
 
 	// dynamic initialization data
 
-	flags map[string]*Flag
-	cmds  map[string]*Command
+	flags     map[string]*Flag
+	cmds      map[string]*Command
+	flagOrder []string
+	cmdOrder  []string
+	action    Handler
+	hooks     hooks
 
 	// dynamic runtime data
 
@@ -35,8 +54,9 @@ type Parser struct {
 // auto-generated usage message along with the name. The name is
 // case sensitive.
 func (p *Parser) Command(name string, description string) *Command {
-	cmd := newCommand(description)
+	cmd := newCommand(name, description, nil, p)
 
+	p.cmdOrder = register(p.cmdOrder, name, p.cmds[name] != nil)
 	p.cmds[name] = cmd
 
 	return cmd
@@ -47,11 +67,47 @@ func (p *Parser) Command(name string, description string) *Command {
 func (p *Parser) Flag(name string, description string) *Flag {
 	flag := newFlag(description)
 
+	p.flagOrder = register(p.flagOrder, name, p.flags[name] != nil)
 	p.flags[name] = flag
 
 	return flag
 }
 
+// Replace the source used to resolve environment variables bound
+// to flags (see Flag.EnvironmentValue). The lookup function follows
+// the semantics of os.LookupEnv, which is also the default source.
+func (p *Parser) Environment(lookup func(key string) (string, bool)) {
+	p.env = lookup
+}
+
+// List flags and commands alphabetically in the usage message
+// instead of the order they have been registered in.
+func (p *Parser) SortUsage(alphabetical bool) {
+	p.sorted = alphabetical
+}
+
+// Wrap the usage message at the given number of columns. If the
+// width is not positive (default), it is taken from the COLUMNS
+// environment variable or the terminal the usage is written to.
+func (p *Parser) UsageWidth(width int) {
+	p.width = width
+}
+
+// Record every problem encountered during parsing instead of only
+// the first one. ParseArgs returns them as ParseErrors. Collecting
+// errors implies continuing on errors.
+func (p *Parser) CollectErrors(collect bool) {
+	p.collect = collect
+}
+
+// Set the maximum edit distance of flag and command names suggested
+// for unknown flags and commands (default: 2). Names differing only
+// in case or sharing a prefix are always considered similar. A
+// distance of zero turns suggestions off.
+func (p *Parser) SuggestionDistance(distance int) {
+	p.suggest = distance
+}
+
 func (p *Parser) NArg() int {
 	return len(p.args)
 }
@@ -60,14 +116,22 @@ func (p *Parser) Args() []string {
 	return p.args
 }
 
+// Write the error message followed by the usage message. Each line
+// of the error message is prefixed with the application name, so
+// all errors collected during parsing (see CollectErrors) are listed.
 func (p *Parser) WriteError(out io.Writer, message string) {
-	fmt.Fprintf(out, "%s: %s\n", p.owner, message)
-
+	p.writeMessage(out, message)
 	p.WriteUsage(out)
 }
 
 func (p *Parser) WriteUsage(out io.Writer) {
-	usage := newUsageWriter(out)
+	width := p.width
+
+	if width <= 0 {
+		width = detectWidth(out, p.env)
+	}
+
+	usage := newUsageWriter(out, p.gnu, width)
 
 	usage.WriteTitle(p.owner)
 
@@ -75,23 +139,15 @@ func (p *Parser) WriteUsage(out io.Writer) {
 		usage.WriteHeader(false)
 	}
 
-	for name, flag := range p.flags {
-		usage.WriteFlag(name, flag, false)
-	}
+	p.writeFlagUsage(usage, p.flagOrder, p.flags, 0)
 
 	if len(p.cmds) > 0 {
 		usage.WriteHeader(true)
 	}
 
-	for name, cmd := range p.cmds {
-		usage.WriteCommand(name, cmd)
-
-		for key, flag := range cmd.flags {
-			usage.WriteFlag(key, flag, true)
-		}
-	}
+	p.writeCommandUsage(usage, p.cmdOrder, p.cmds, 0)
 
-	usage.WriteFooter()
+	usage.WriteFooter().Flush()
 }
 
 // Proxy method for WriteError using os.Stderr as output writer.
@@ -108,28 +164,20 @@ func (p *Parser) PrintUsage() {
 // only flags, commands and command flags, i.e. the application name
 // from os.Args or similar slices should be omitted.
 // The return value indicates problems during processing. If the
-// parser is configured to continue on errors, the last encountered
-// error is returned.
+// parser is configured to continue on errors, the first encountered
+// error is returned, unless all errors are collected.
 // Calling this method multiple times will overwrite any previous
 // parsing results as it is reset first before writing new data.
 // (see Reset())
-func (p *Parser) ParseArgs(argv []string) (err error) {
-	// use root flags first
-	var flags map[string]*Flag = p.flags
-	var cmd *Command = nil
+func (p *Parser) ParseArgs(argv []string) error {
+	var cmd *Command = nil // the deepest command matched so far
 	var e *errorTracker = newErrorTracker(!p.lenient, true)
-	var args []string = make([]string, 0, len(argv)) // pessimistic size
-	var cmdArgs bool = false                         // where to append non-flags
+	var args []string = nil // arguments of the deepest command
+	var rest []string = nil // arguments following the flag terminator
 
 	defer func() {
-		if r := recover(); nil != r {
-			// the error might also come from areas
-			// not covered by the error tracker
-			err = fmt.Errorf("%v", r)
-		}
-
 		if nil == cmd {
-			cmd = newCommand("") // dummy value
+			cmd = newCommand("", "", nil, nil) // dummy value
 		}
 
 		// even though we might have encountered
@@ -141,31 +189,164 @@ func (p *Parser) ParseArgs(argv []string) (err error) {
 		cmd.args = args
 	}()
 
+	if p.collect {
+		e = newErrorCollector()
+	}
+
 	p.args = make([]string, 0, 0) // will stay this way or overwritten
 	p.invokes++
+	p.reset()
+
+	if cmd, args, rest = p.walk(argv, flagAssigner{}, e); nil != rest {
+		// we do not want the flag terminator in the array
+		p.args = rest
+	}
+
+	if false == e.Halted() {
+		p.resolve(cmd, e)
+	}
+
+	return e.Error()
+}
+
+// Walk the command-line applying the rules of ParseArgs. Flags are
+// passed to the visitor, commands are descended as long as no
+// arguments have been assigned to the current command. Returns the
+// deepest command matched, its arguments and the arguments following
+// the flag terminator (nil if there is none).
+func (p *Parser) walk(argv []string, visitor flagVisitor, e *errorTracker) (*Command, []string, []string) {
+	var cmd *Command = nil
+	var args []string = make([]string, 0, len(argv)) // pessimistic size
+
+	for index := 0; index < len(argv) && false == e.Halted(); index++ {
+		arg := argv[index]
 
-	for index, arg := range argv {
 		if arg == flagTermination {
-			// we do not want the flag terminator in the array
-			if len(argv) > index {
-				p.args = argv[index+1:]
+			return cmd, args, argv[index+1:]
+		} else if p.isFlag(arg) {
+			// the flag might consume the next argument as value
+			var consumed int
+			var err error
+
+			if p.gnu {
+				consumed, err = parseGNUFlag(argv[index:], index, p.scope(cmd), p.shortScope(cmd), visitor)
+			} else {
+				consumed, err = parseFlag(argv[index:], index, p.scope(cmd), visitor)
+			}
+
+			index += consumed
+
+			storeError(e, p.suggestFlags(err, cmd))
+		} else if nil == cmd {
+			if cmd = p.cmds[arg]; nil == cmd {
+				// argument is neither a flag nor a valid command
+				e.Store(&UnknownCommandError{arg, arg, index, suggest(arg, p.cmdOrder, p.suggest)})
 			}
-			break
-		} else if strings.HasPrefix(arg, flagPrefix) {
-			e.Store(parseFlag(arg, flags))
-		} else if cmdArgs {
+		} else if child, ok := cmd.cmds[arg]; ok && len(args) == 0 {
+			// descend as long as no arguments have been
+			// assigned to the current command
+			cmd = child
+		} else {
 			// append to command args
 			args = append(args, arg)
-		} else if cmd, cmdArgs = p.cmds[arg]; cmdArgs {
-			// use command flags from now on.
-			flags = cmd.flags
-		} else {
-			// argument is neither a flag nor a valid command
-			e.Store(fmt.Sprintf("No such command '%s'", arg))
 		}
 	}
 
-	return e.Error()
+	return cmd, args, nil
+}
+
+// Whether the argument is treated as flag by ParseArgs.
+func (p *Parser) isFlag(arg string) bool {
+	return strings.HasPrefix(arg, flagPrefix) && (false == p.gnu || arg != flagPrefix)
+}
+
+// Assign the default action run by Run if no command has been
+// triggered or none of the triggered commands has an action.
+func (p *Parser) Action(action Handler) {
+	p.action = action
+}
+
+// Register a hook run before the action of any command (see
+// Parser.Run). Hooks of the parser run before those of commands.
+func (p *Parser) Before(hook Handler) {
+	p.hooks.before = append(p.hooks.before, hook)
+}
+
+// Register a hook run after the action of any command, even if the
+// action or a Before hook failed. Hooks of the parser run after
+// those of commands.
+func (p *Parser) After(hook Handler) {
+	p.hooks.after = append(p.hooks.after, hook)
+}
+
+// Wrap the action of any command. The middleware registered first
+// is the outermost one.
+func (p *Parser) Use(middleware ...Middleware) {
+	p.hooks.middleware = append(p.hooks.middleware, middleware...)
+}
+
+// Cancel the context passed to actions (see RunContext) if the
+// process receives an interrupt or termination signal. A second
+// signal terminates the process immediately.
+func (p *Parser) CancelOnSignal(enable bool) {
+	p.signals = enable
+}
+
+// Proxy method for RunContext using the background context.
+func (p *Parser) Run(argv []string) error {
+	return p.RunContext(context.Background(), argv)
+}
+
+// Parse the arguments and run the action of the triggered command
+// (see Command.Action) along with the hooks of the parser and the
+// triggered commands (see Parser.Before). The usage message is
+// written instead if the help flag is present; it is registered
+// unless the application defines a flag of that name itself (see
+// Parser.HelpFlag). Completion candidates are written instead if the first
+// argument is CompletionCommand. Parse errors are returned without
+// running any action, otherwise the error of the action. The context
+// is available to the action through Context.Context.
+func (p *Parser) RunContext(ctx context.Context, argv []string) error {
+	help := p.HelpFlag()
+
+	if len(argv) > 0 && argv[0] == CompletionCommand {
+		p.PrintCandidates(argv[1:])
+
+		return nil
+	}
+
+	err := p.ParseArgs(argv)
+
+	if help.present {
+		p.PrintUsage()
+
+		return nil
+	} else if nil != err {
+		return err
+	}
+
+	if p.signals {
+		var release func()
+
+		ctx, release = p.notifySignals(ctx)
+		defer release()
+	}
+
+	run := &Context{ctx, p, p.triggered()}
+
+	for cmd := run.cmd; nil != cmd; cmd = cmd.parent {
+		if nil != cmd.action {
+			return p.dispatch(run, cmd.action)
+		}
+	}
+
+	if nil != p.action {
+		return p.dispatch(run, p.action)
+	} else if nil != run.cmd {
+		return &MissingActionError{run.cmd.name}
+	}
+
+	return &MissingActionError{""}
 }
 
 // This method is similar to the Parse method of the flag package.
@@ -180,11 +361,35 @@ func (p *Parser) Parsed() bool {
 }
 
 // Returns true if the provided command was triggered during the
-// last parsing process.
+// last parsing process. For nested commands only the deepest
+// matched command is considered triggered.
 func (p *Parser) Triggered(cmd *Command) bool {
 	return p.trigger == cmd
 }
 
+// Returns the commands matched during the last parsing process,
+// starting with the top-level command. The slice is empty if no
+// command has been triggered.
+func (p *Parser) TriggeredPath() []*Command {
+	if cmd := p.triggered(); nil != cmd {
+		return cmd.path()
+	}
+
+	return []*Command{}
+}
+
+// Find a flag along with its current value (see Getter). The
+// lookup considers the flags of the command triggered during the
+// last parsing process and the application flags. If no such flag
+// exists, both return values are nil.
+func (p *Parser) Lookup(name string) (*Flag, interface{}) {
+	if flag := p.scope(p.triggered())(name); nil != flag {
+		return flag, getValue(flag.value)
+	}
+
+	return nil, nil
+}
+
 // Create a new parser instance. The application name is provided
 // here as it is not expected to be part of any parsing input. It is
 // used in the usage message.
@@ -197,35 +402,373 @@ func NewParser(applicationName string, continueOnError bool) *Parser {
 	args := []string{}
 
 	return &Parser{continueOnError,
+		false,
+		false,
 		applicationName,
+		os.LookupEnv,
+		false,
+		0,
+		suggestDistance,
+		false,
+		os.Exit,
+		"",
 		flags,
 		cmds,
+		[]string{},
+		[]string{},
+		nil,
+		hooks{},
 		args,
 		nil,
 		0}
 }
 
-func parseFlag(needle string, haystack map[string]*Flag) string {
-	flag := strings.TrimPrefix(needle, flagPrefix)
-	parts := strings.SplitN(flag, flagValueSep, 2)
+// Create a new parser instance which accepts the POSIX/GNU flag
+// notation: long flags are prefixed with two dashes (--name,
+// --name=value or --name value) and single character flags (see
+// Flag.Short) with a single dash (-v, -abc, -j4 or -j 4).
+// Apart from the syntax, the parser behaves like the one created
+// by NewParser.
+func NewGNUParser(applicationName string, continueOnError bool) *Parser {
+	p := NewParser(applicationName, continueOnError)
+	p.gnu = true
+
+	return p
+}
+
+// Process a flag in the single dash notation (-name or -name=value).
+// The first element of argv is the flag itself, which is found at
+// the given index of the command-line. If the flag requires a value
+// which is not attached, the next element is consumed. The first
+// return value denotes the number of consumed elements.
+func parseFlag(argv []string, index int, lookup func(string) *Flag, visitor flagVisitor) (int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(argv[0], flagPrefix), flagValueSep, 2)
 	key := parts[0]
-	val := ""
+	flag := lookup(key)
+
+	if nil == flag {
+		return 0, &UnknownFlagError{key, argv[0], index, nil}
+	} else if len(parts) > 1 {
+		// an explicit separator always denotes the value,
+		// even if it is empty
+		return 0, visitor.assign(key, flag, parts[1], argv[0], index)
+	}
 
-	if len(parts) > 1 {
-		val = parts[1]
+	return parseDetachedValue(argv, index, flagPrefix, key, flag, visitor)
+}
+
+// Assign the argument following the flag as its value if the value
+// is required. Boolean flags and flags with optional values never
+// consume the next argument, neither does the flag terminator.
+// Omitting an optional value passes an empty string to values which
+// accept it; other values keep their state.
+func parseDetachedValue(argv []string, index int, prefix string, key string, flag *Flag, visitor flagVisitor) (int, error) {
+	if isBoolFlag(flag.value) || (false == flag.valueReq && acceptsEmpty(flag.value)) {
+		return 0, visitor.assign(key, flag, "", argv[0], index)
+	} else if false == flag.valueReq {
+		visitor.omitted(flag, index)
+
+		return 0, nil
+	} else if len(argv) > 1 && argv[1] != flagTermination {
+		return 1, visitor.assign(key, flag, argv[1], argv[0], index)
 	}
 
-	if flag, ok := haystack[key]; ok {
-		if err := flag.value.Set(val); nil != err {
-			if msg := err.Error(); len(msg) > 0 {
-				return msg
-			} else {
-				return fmt.Sprintf("Unable to write value '%s' to flag '%s'", val, key)
+	visitor.missing(flag, index)
+
+	return 0, &MissingValueError{key, argv[0], index, flag.valueName, prefix}
+}
+
+// Receives the flags found while walking the command-line (see
+// Parser.walk).
+type flagVisitor interface {
+	// a value for the flag was found in the given argument
+	assign(name string, flag *Flag, val string, input string, index int) error
+	// the flag requires a value, but none follows
+	missing(flag *Flag, index int)
+	// the optional value of the flag is omitted
+	omitted(flag *Flag, index int)
+}
+
+// Assigns the values found on the command-line (see ParseArgs).
+type flagAssigner struct{}
+
+func (a flagAssigner) assign(name string, flag *Flag, val string, input string, index int) error {
+	return setFlag(name, flag, val, input, index)
+}
+
+func (a flagAssigner) missing(flag *Flag, index int) {
+	flag.seen = true
+}
+
+func (a flagAssigner) omitted(flag *Flag, index int) {
+	flag.seen = true
+}
+
+// Write the value of a flag found in the given command-line argument.
+// The flag is only marked as present if the value is accepted.
+func setFlag(name string, flag *Flag, val string, input string, index int) error {
+	if err := callSet(name, flag.value, val); nil != err {
+		if _, ok := err.(*ValuePanicError); ok {
+			return err
+		}
+
+		return &InvalidValueError{name, input, index, val, "", err}
+	}
+
+	flag.mark(Provenance{SourceCommandLine, "", "", 0, index})
+
+	return nil
+}
+
+// Invoke Value.Set and convert a panic into a ValuePanicError.
+func callSet(name string, value Value, val string) (err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = &ValuePanicError{name, val, r, debug.Stack()}
+		}
+	}()
+
+	return value.Set(val)
+}
+
+// Store an error. Panics of flag values halt the processing.
+func storeError(e *errorTracker, err error) {
+	if _, ok := err.(*ValuePanicError); ok {
+		e.Abort(err)
+	} else {
+		e.Store(err)
+	}
+}
+
+// Apply the environment values of the flags visible to the command
+// and check the presence of required flags.
+func (p *Parser) resolve(cmd *Command, e *errorTracker) {
+	path := []*Command{}
+
+	if nil != cmd {
+		path = cmd.path()
+	}
+
+	// flags absent from the command-line fall back to
+	// their environment variables (if any)
+	for _, name := range p.flagOrder {
+		if storeError(e, resolveFlag(name, p.flags[name], p.env)); e.Halted() {
+			return
+		}
+	}
+
+	for _, c := range path {
+		for _, name := range c.flagOrder {
+			if storeError(e, resolveFlag(name, c.flags[name], p.env)); e.Halted() {
+				return
 			}
 		}
+	}
+
+	// followed by the configuration file
+	if p.configure(path, e); e.Halted() {
+		return
+	}
+
+	prefix := flagPrefix
+
+	if p.gnu {
+		prefix = flagLongPrefix
+	}
+
+	missing := missingFlags(p.flags, prefix)
+
+	for _, c := range path {
+		missing = append(missing, missingFlags(c.flags, prefix)...)
+	}
+
+	if len(missing) > 0 {
+		e.Store(&MissingRequiredError{missing})
+	}
+}
+
+// Clear the runtime data of all registered flags.
+func (p *Parser) reset() {
+	for _, flag := range p.flags {
+		flag.clear()
+	}
+
+	for _, cmd := range p.cmds {
+		cmd.reset()
+	}
+}
+
+// Returns the command triggered during the last parsing process
+// or nil if none has been triggered.
+func (p *Parser) triggered() *Command {
+	if nil == p.trigger || nil == p.trigger.owner {
+		return nil
+	}
+
+	return p.trigger
+}
+
+// Write each line of the message prefixed with the application name.
+func (p *Parser) writeMessage(out io.Writer, message string) {
+	for _, line := range strings.Split(message, "\n") {
+		fmt.Fprintf(out, "%s: %s\n", p.owner, line)
+	}
+}
+
+// Returns the flag requesting the usage message (see RunContext). It
+// is registered (as boolean flag) if the application has not done so.
+// Run registers it as well; calling it beforehand includes the flag in
+// usage messages and completion scripts written before Run.
+func (p *Parser) HelpFlag() *Flag {
+	if flag, ok := p.flags[helpFlagName]; ok {
+		return flag
+	}
+
+	flag := p.Flag(helpFlagName, helpFlagDesc)
+
+	if nil == lookupShort(helpFlagShort, p.flags) {
+		flag.Short(helpFlagShort)
+	}
+
+	flag.Bool(false)
+
+	return flag
+}
+
+// Create a flag lookup function for the given command. Without
+// a command only the application flags are visible.
+func (p *Parser) scope(cmd *Command) func(string) *Flag {
+	if nil == cmd {
+		return func(name string) *Flag {
+			return p.flags[name]
+		}
+	}
+
+	return cmd.lookup
+}
+
+// Attach the names of similar flags visible to the command to an
+// unknown flag error. Single character flags are not considered.
+func (p *Parser) suggestFlags(err error, cmd *Command) error {
+	unknown, ok := err.(*UnknownFlagError)
+
+	if false == ok || (p.gnu && false == strings.HasPrefix(unknown.Input, flagLongPrefix)) {
+		return err
+	}
+
+	names := append([]string{}, p.flagOrder...)
 
-		return ""
+	if nil != cmd {
+		for c := cmd; nil != c; c = c.parent {
+			names = append(names, c.flagOrder...)
+		}
+	}
+
+	unknown.Suggestions = suggest(unknown.Name, names, p.suggest)
+
+	return unknown
+}
+
+// Create a lookup function for single character flags (see
+// Flag.Short) visible to the given command.
+func (p *Parser) shortScope(cmd *Command) func(rune) *Flag {
+	if nil == cmd {
+		return func(short rune) *Flag {
+			return lookupShort(short, p.flags)
+		}
 	}
 
-	return fmt.Sprintf("No such flag '%s'", key)
+	return cmd.lookupShort
+}
+
+// Describe the given flags grouped by their group names (see
+// Flag.Group). Flags without a group are listed first.
+func (p *Parser) writeFlagUsage(usage *usageWriter, order []string, flags map[string]*Flag, depth int) {
+	groups, members := groupNames(order, p.sorted, func(name string) string {
+		return flags[name].group
+	})
+
+	for _, group := range groups {
+		if len(group) > 0 {
+			usage.WriteGroup(group, depth)
+		}
+
+		for _, name := range members[group] {
+			usage.WriteFlag(name, flags[name], depth)
+		}
+	}
+}
+
+// Describe the given commands along with their flags and nested
+// commands grouped by their categories (see Command.Category).
+func (p *Parser) writeCommandUsage(usage *usageWriter, order []string, cmds map[string]*Command, depth int) {
+	groups, members := groupNames(order, p.sorted, func(name string) string {
+		return cmds[name].category
+	})
+
+	for _, group := range groups {
+		if len(group) > 0 {
+			usage.WriteGroup(group, depth)
+		}
+
+		for _, name := range members[group] {
+			cmd := cmds[name]
+
+			usage.WriteCommand(name, cmd, depth)
+
+			p.writeFlagUsage(usage, cmd.flagOrder, cmd.flags, depth+1)
+			p.writeCommandUsage(usage, cmd.cmdOrder, cmd.cmds, depth+1)
+		}
+	}
+}
+
+// Collect the names of all required flags which have not been
+// provided. The names are sorted and include the given flag prefix.
+func missingFlags(flags map[string]*Flag, prefix string) []string {
+	missing := make([]string, 0, len(flags))
+
+	for name, flag := range flags {
+		if flag.required && false == flag.present && false == flag.seen {
+			missing = append(missing, prefix+name)
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing
+}
+
+// Apply the environment value of a flag which has not been
+// provided on the command-line. Empty variables are ignored. The
+// variable of a repeatable flag is split into several values.
+func resolveFlag(name string, flag *Flag, lookup func(string) (string, bool)) error {
+	if flag.present || len(flag.env) == 0 {
+		return nil
+	}
+
+	val, ok := lookup(flag.env)
+
+	if false == ok || len(val) == 0 {
+		return nil
+	}
+
+	elems := []string{val}
+
+	if isRepeatable(flag.value) {
+		elems = splitValue(val, &flag.envSep)
+	}
+
+	for _, elem := range elems {
+		if err := callSet(name, flag.value, elem); nil != err {
+			if _, ok := err.(*ValuePanicError); ok {
+				return err
+			}
+
+			return &InvalidValueError{name, val, -1, elem, flag.env, err}
+		}
+	}
+
+	flag.mark(Provenance{SourceEnvironment, flag.env, "", 0, -1})
+
+	return nil
 }
@@ -1,8 +1,15 @@
 package command
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"assert"
 )
@@ -302,6 +309,479 @@ func TestParseArgsAppArgsOnly(t *testing.T) {
 	assert.StringArrayEquals(t, "app args", unit.Args(), argv[1:])
 }
 
+func TestParseArgsEnvironment(t *testing.T) {
+	var app int
+	var cmd int
+	var other int
+	env := map[string]string{"APP": "3", "CMD": "5", "OTHER": "7"}
+	unit := NewParser("testing", true)
+	cmd1 := unit.Command("cmd1", "test command 1")
+	cmd2 := unit.Command("cmd2", "test command 2")
+
+	unit.Flag("app", "test flag").EnvironmentValue("APP").IntVar(&app)
+	cmd1.Flag("cmd", "test flag").EnvironmentValue("CMD").IntVar(&cmd)
+	cmd2.Flag("other", "test flag").EnvironmentValue("OTHER").IntVar(&other)
+	unit.Environment(newEnvironment(env))
+
+	if err := unit.ParseArgs([]string{"cmd1"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "app flag", app, 3)
+	assert.Equals(t, "cmd flag", cmd, 5)
+	assert.Equals(t, "untriggered cmd flag", other, 0)
+}
+
+func TestParseArgsEnvironmentPrecedence(t *testing.T) {
+	var app int
+	env := map[string]string{"APP": "3"}
+	unit := NewParser("testing", true)
+
+	unit.Flag("app", "test flag").EnvironmentValue("APP").IntVar(&app)
+	unit.Environment(newEnvironment(env))
+
+	if err := unit.ParseArgs([]string{"-app=9"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "app flag", app, 9)
+
+	app = 0
+
+	if err := unit.ParseArgs([]string{}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "app flag", app, 3)
+}
+
+func TestParseArgsEnvironmentError(t *testing.T) {
+	var app bool
+	env := map[string]string{"APP": "nope"}
+	unit := NewParser("testing", false)
+
+	unit.Flag("app", "test flag").EnvironmentValue("APP").BoolVar(&app)
+	unit.Environment(newEnvironment(env))
+
+	if err := unit.ParseArgs([]string{}); nil == err {
+		t.Fatal("invalid environment value caused no error")
+	}
+}
+
+func TestParseArgsRepeatable(t *testing.T) {
+	env := map[string]string{"TAGS": "x:y", "PORTS": "80,443"}
+	unit := NewParser("testing", false)
+	tags := unit.Flag("tag", "test flag").Value("TAG", true).Split(",").EnvironmentValue("TAGS").EnvironmentSeparator(":").Strings("default")
+	labels := unit.Flag("label", "test flag").StringMap()
+	ports := unit.Flag("port", "test flag").EnvironmentValue("PORTS").Ints()
+
+	unit.Environment(newEnvironment(env))
+
+	if err := unit.ParseArgs([]string{"-tag=a,b", "-label=env=prod", "-tag", "c", "-label=team=ops"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.StringArrayEquals(t, "tags", *tags, []string{"a", "b", "c"})
+	assert.Equals(t, "label count", len(labels), 2)
+	assert.Equals(t, "label env", labels["env"], "prod")
+	assert.Equals(t, "label team", labels["team"], "ops")
+	assert.Equals(t, "port count", len(*ports), 2)
+	assert.Equals(t, "port", (*ports)[1], 443)
+
+	unit.flags["tag"].EnvironmentSeparator("")
+
+	if err := unit.ParseArgs([]string{}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.StringArrayEquals(t, "unsplit env tags", *tags, []string{"x:y"})
+}
+
+func TestParseArgsRepeatableReparse(t *testing.T) {
+	unit := NewParser("testing", false)
+	tags := unit.Flag("tag", "test flag").Strings("default")
+	ports := unit.Flag("port", "test flag").Ints(80)
+	labels := unit.Flag("label", "test flag").StringMap()
+
+	var tests = []struct {
+		argv   []string
+		tags   []string
+		ports  int
+		labels int
+	}{
+		{[]string{"-tag=a", "-port=1", "-port=2", "-label=a=1"}, []string{"a"}, 2, 1},
+		{[]string{"-tag=b", "-label=b=2"}, []string{"b"}, 1, 1},
+		{[]string{}, []string{"default"}, 1, 0},
+	}
+
+	for _, test := range tests {
+		if err := unit.ParseArgs(test.argv); nil != err {
+			t.Fatal("failed to parse arguments:", err)
+		}
+
+		assert.StringArrayEquals(t, "tags", *tags, test.tags)
+		assert.Equals(t, "ports", len(*ports), test.ports)
+		assert.Equals(t, "labels", len(labels), test.labels)
+	}
+
+	assert.Equals(t, "default port", (*ports)[0], 80)
+}
+
+func TestParseArgsTypedValues(t *testing.T) {
+	unit := NewParser("testing", false)
+	name := unit.Flag("name", "test flag").String("anon")
+	limit := unit.Flag("limit", "test flag").ByteSize(1 << 20)
+	timeout := unit.Flag("timeout", "test flag").Duration(time.Second)
+	endpoint := unit.Flag("endpoint", "test flag").URL("http://localhost")
+	filter := unit.Flag("filter", "test flag").Regexp(".*")
+
+	assert.Equals(t, "default", unit.flags["limit"].defValue, "1MiB")
+	assert.Equals(t, "description", unit.flags["name"].Description(), "test flag")
+
+	if err := unit.ParseArgs([]string{"-limit=2GiB", "-timeout=1m", "-endpoint=https://example.com", "-filter=^a"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "string", *name, "anon")
+	assert.Equals(t, "byte size", *limit, uint64(2<<30))
+	assert.Equals(t, "duration", *timeout, time.Minute)
+	assert.Equals(t, "url", endpoint.Host, "example.com")
+	assert.False(t, "regexp", (*filter).MatchString("ba"))
+}
+
+func TestParseArgsValueRequired(t *testing.T) {
+	var jobs int
+	unit := NewParser("testing", false)
+
+	unit.Flag("jobs", "test flag").Value("NUM", true).IntVar(&jobs)
+	unit.Flag("quiet", "test flag").Value("BOOL", true).Bool(false)
+
+	if err := unit.ParseArgs([]string{"-jobs"}); nil == err {
+		t.Fatal("missing flag value caused no error")
+	} else {
+		assert.Equals(t, "error", err.Error(), "Flag -jobs requires a value NUM")
+	}
+
+	if err := unit.ParseArgs([]string{"-quiet", "-jobs=2"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "jobs flag", jobs, 2)
+}
+
+func TestParseArgsDetachedValue(t *testing.T) {
+	var config string
+	var quiet bool
+	var level int
+	unit := NewParser("testing", false)
+	cmd := unit.Command("cmd1", "test command 1")
+
+	unit.Flag("config", "test flag").Value("FILE", true).Var(&stringTestValue{&config})
+	unit.Flag("quiet", "test flag").Value("BOOL", true).BoolVar(&quiet)
+	unit.Flag("level", "test flag").Value("LEVEL", false).IntVar(&level)
+
+	var tests = []struct {
+		argv   []string
+		config string
+		quiet  bool
+		args   []string
+	}{
+		{[]string{"-config", "app.rc", "cmd1"}, "app.rc", false, []string{}},
+		{[]string{"-config=", "cmd1"}, "", false, []string{}},
+		{[]string{"cmd1", "-config", "-quiet"}, "-quiet", false, []string{}},
+		{[]string{"cmd1", "-quiet", "arg"}, "unset", true, []string{"arg"}},
+		{[]string{"-level", "cmd1", "arg"}, "unset", false, []string{"arg"}},
+	}
+
+	for _, test := range tests {
+		config, quiet = "unset", false
+
+		assert.That(t, cmd, newParserMatcher(unit, test.argv))
+
+		assert.Equals(t, "config flag", config, test.config)
+		assert.Equals(t, "quiet flag", quiet, test.quiet)
+
+		assert.StringArrayEquals(t, "cmd args", cmd.Args(), test.args)
+	}
+
+	if err := unit.ParseArgs([]string{"cmd1", "-config"}); nil == err {
+		t.Error("trailing flag without value caused no error")
+	}
+
+	if err := unit.ParseArgs([]string{"-config", "--", "arg"}); nil == err {
+		t.Error("flag terminator was consumed as flag value")
+	}
+}
+
+func TestParseArgsOptionalValue(t *testing.T) {
+	var count int
+	unit := NewParser("testing", false)
+
+	unit.Flag("count", "test flag").Value("NUM", false).Var(&countTestValue{&count})
+
+	if err := unit.ParseArgs([]string{"-count", "-count"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "count", count, 2)
+
+	if err := unit.ParseArgs([]string{"-count=5", "-count"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "count", count, 6)
+}
+
+func TestParseArgsOmittedValue(t *testing.T) {
+	unit := NewParser("testing", false)
+	version := unit.Flag("version", "test flag").Bool(false)
+	verbose := unit.Flag("verbose", "test flag").Value("LEVEL", false).Required().Int(1)
+
+	if err := unit.ParseArgs([]string{"-version", "-verbose"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.True(t, "version flag", *version)
+	assert.Equals(t, "verbose flag", *verbose, 1)
+
+	origin, _ := unit.Provenance("verbose")
+
+	assert.Equals(t, "verbose origin", origin, defaultProvenance())
+}
+
+func TestParseArgsRequired(t *testing.T) {
+	env := map[string]string{"CFLAG": "1"}
+	unit := NewParser("testing", true)
+	cmd := unit.Command("cmd1", "test command 1")
+
+	unit.Flag("bflag", "test flag").Required().Bool(false)
+	unit.Flag("aflag", "test flag").Required().Bool(false)
+	cmd.Flag("cflag", "test flag").EnvironmentValue("CFLAG").Required().Bool(false)
+	cmd.Flag("dflag", "test flag").Required().Bool(false)
+	unit.Environment(newEnvironment(env))
+
+	if err := unit.ParseArgs([]string{"-aflag"}); nil == err {
+		t.Fatal("missing required flag caused no error")
+	} else {
+		assert.Equals(t, "error", err.Error(), "Missing required flag(s) -bflag")
+	}
+
+	if err := unit.ParseArgs([]string{"cmd1"}); nil == err {
+		t.Fatal("missing required flags caused no error")
+	} else {
+		assert.Equals(t, "error", err.Error(), "Missing required flag(s) -aflag, -bflag, -dflag")
+	}
+
+	if err := unit.ParseArgs([]string{"-aflag", "-bflag", "cmd1", "-dflag"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+}
+
+func TestParseArgsNested(t *testing.T) {
+	var verbose bool
+	var force bool
+	var fetch bool
+	unit := NewParser("testing", false)
+	remote := unit.Command("remote", "test command")
+	add := remote.Command("add", "nested test command")
+
+	unit.Flag("verbose", "test flag").BoolVar(&verbose)
+	remote.Flag("force", "test flag").BoolVar(&force)
+	add.Flag("fetch", "test flag").BoolVar(&fetch)
+
+	argv := []string{"remote", "add", "-fetch", "-force", "-verbose", "origin", "add"}
+
+	assert.That(t, add, newParserMatcher(unit, argv))
+
+	assert.Equals(t, "description", add.Description(), "nested test command")
+	assert.True(t, "app flag", verbose)
+	assert.True(t, "parent flag", force)
+	assert.True(t, "cmd flag", fetch)
+	assert.False(t, "parent triggered", unit.Triggered(remote))
+
+	assert.StringArrayEquals(t, "cmd args", add.Args(), []string{"origin", "add"})
+	assert.Equals(t, "path", unit.TriggeredPath(), []*Command{remote, add})
+
+	if err := unit.ParseArgs([]string{"remote", "-fetch"}); nil == err {
+		t.Error("nested command flag was visible to its parent")
+	}
+
+	if err := unit.ParseArgs([]string{"-verbose"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	assert.Equals(t, "empty path", unit.TriggeredPath(), []*Command{})
+}
+
+func TestParseArgsNestedShadowing(t *testing.T) {
+	var app bool
+	var cmd bool
+	unit := NewParser("testing", false)
+	remote := unit.Command("remote", "test command")
+
+	unit.Flag("dry", "test flag").BoolVar(&app)
+	remote.Flag("dry", "test flag").BoolVar(&cmd)
+
+	assert.That(t, remote, newParserMatcher(unit, []string{"remote", "-dry"}))
+
+	assert.False(t, "app flag", app)
+	assert.True(t, "cmd flag", cmd)
+}
+
+func TestLookup(t *testing.T) {
+	unit := NewParser("testing", false)
+	cmd := unit.Command("cmd1", "test command 1")
+	app := unit.Flag("level", "test flag")
+	shadow := cmd.Flag("level", "test flag")
+
+	app.Value("LEVEL", true).Int(1)
+	shadow.Value("LEVEL", true).Int(2)
+
+	if err := unit.ParseArgs([]string{"-level=3"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	flag, value := unit.Lookup("level")
+
+	assert.True(t, "app flag", app == flag)
+	assert.Equals(t, "app value", value, 3)
+
+	flag, value = cmd.Lookup("level")
+
+	assert.True(t, "cmd flag", shadow == flag)
+	assert.Equals(t, "cmd value", value, 2)
+
+	if err := unit.ParseArgs([]string{"cmd1", "-level=4"}); nil != err {
+		t.Fatal("failed to parse arguments:", err)
+	}
+
+	flag, value = unit.Lookup("level")
+
+	assert.True(t, "triggered cmd flag", shadow == flag)
+	assert.Equals(t, "triggered cmd value", value, 4)
+
+	flag, value = unit.Lookup("missing")
+
+	assert.True(t, "missing flag", nil == flag && nil == value)
+}
+
+func TestRun(t *testing.T) {
+	var trace []string
+	unit := NewParser("testing", false)
+	remote := unit.Command("remote", "test command")
+
+	remote.Command("add", "test command")
+	unit.Command("test", "test command").Action(func(ctx *Context) error {
+		trace = append(trace, "test")
+		trace = append(trace, ctx.Args()...)
+
+		return nil
+	})
+
+	remote.Flag("name", "test flag").Int(1)
+	remote.Action(func(ctx *Context) error {
+		trace = append(trace, ctx.Command().Name(), fmt.Sprint(ctx.Value("name")))
+
+		return fmt.Errorf("remote failed")
+	})
+
+	assert.True(t, "test", nil == unit.Run([]string{"test", "a", "b"}))
+	assert.StringArrayEquals(t, "test trace", trace, []string{"test", "a", "b"})
+
+	trace = nil
+
+	assert.Equals(t, "ancestor", unit.Run([]string{"remote", "add", "-name=2"}).Error(), "remote failed")
+	assert.StringArrayEquals(t, "ancestor trace", trace, []string{"add", "2"})
+
+	trace = nil
+
+	assert.Equals(t, "parse error", unit.Run([]string{"remote", "-name=x"}).Error(), "Invalid value for flag 'name' in '-name=x': 'x' is not a valid integer value.")
+	assert.Equals(t, "no trace", len(trace), 0)
+
+	err := unit.Run([]string{})
+
+	assert.True(t, "no command", errors.Is(err, ErrMissingAction))
+	assert.Equals(t, "no command message", err.Error(), "Missing command")
+
+	unit.Action(func(ctx *Context) error {
+		trace = append(trace, "default")
+
+		assert.True(t, "no command", nil == ctx.Command())
+		assert.Equals(t, "no args", ctx.NArg(), 0)
+		assert.Equals(t, "parser", ctx.Parser(), unit)
+
+		return nil
+	})
+
+	assert.True(t, "default", nil == unit.Run([]string{}))
+	assert.StringArrayEquals(t, "default trace", trace, []string{"default"})
+}
+
+func TestRunHelp(t *testing.T) {
+	unit := NewGNUParser("testing", false)
+	called := false
+
+	unit.Flag("host", "test flag").Short('h').Bool(false)
+	unit.Action(func(ctx *Context) error {
+		called = true
+
+		return nil
+	})
+
+	defer silenceStdout()()
+
+	assert.True(t, "help", nil == unit.Run([]string{"--help", "--unknown"}))
+	assert.False(t, "action", called)
+	assert.Equals(t, "short", unit.flags["help"].short, rune(0))
+
+	unit = NewParser("testing", false)
+	help := unit.Flag("help", "test flag").Bool(false)
+
+	assert.True(t, "custom", nil == unit.Run([]string{"-help"}))
+	assert.True(t, "custom value", *help)
+	assert.Equals(t, "custom desc", unit.flags["help"].desc, "test flag")
+}
+
+func TestHelpFlagBeforeRun(t *testing.T) {
+	var out bytes.Buffer
+	unit := NewGNUParser("testing", false)
+
+	unit.WriteUsage(&out)
+	unit.WriteCandidates(&out, []string{"-"})
+
+	assert.False(t, "usage", strings.Contains(out.String(), "--help"))
+	assert.Equals(t, "flags", len(unit.flags), 0)
+
+	unit.HelpFlag()
+	out.Reset()
+	unit.WriteUsage(&out)
+
+	assert.True(t, "usage", strings.Contains(out.String(), "-h, --help"))
+
+	out.Reset()
+
+	if err := unit.WriteCompletion(&out, ShellBash); nil != err {
+		t.Fatal("failed to write completion:", err)
+	}
+
+	assert.True(t, "completion", strings.Contains(out.String(), "--help"))
+}
+
+func TestRunContext(t *testing.T) {
+	type key struct{}
+
+	unit := NewParser("testing", false)
+	parent := context.WithValue(context.Background(), key{}, "value")
+
+	unit.Action(func(ctx *Context) error {
+		assert.Equals(t, "value", ctx.Context().Value(key{}), "value")
+
+		return exitTestError(5)
+	})
+
+	assert.Equals(t, "error", unit.RunContext(parent, []string{}), exitTestError(5))
+}
+
 func newArgs(appFlag bool, cmdFlag bool, appArgs []string, cmdArgs []string) ([]string, int) {
 	var aa int = len(appArgs)
 	var ca int = len(cmdArgs)
@@ -345,3 +825,55 @@ func newParserTestUnit(parseFlag *bool, cmdFlag *bool) (*Parser, *Command) {
 func newParserMatcher(p *Parser, argv []string) *parserMatcher {
 	return &parserMatcher{p, argv, ""}
 }
+
+func newEnvironment(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := vars[key]
+
+		return val, ok
+	}
+}
+
+type stringTestValue struct {
+	out *string
+}
+
+func (s *stringTestValue) Set(value string) error {
+	*(s.out) = value
+
+	return nil
+}
+
+// Counts occurrences without a value, a number overrides the count.
+type countTestValue struct {
+	out *int
+}
+
+func (c *countTestValue) AcceptsEmpty() bool {
+	return true
+}
+
+func (c *countTestValue) Set(value string) error {
+	if len(value) == 0 {
+		*(c.out)++
+
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	*(c.out) = n
+
+	return err
+}
+
+// Redirect stdout to the null device until the returned function
+// is called.
+func silenceStdout() func() {
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+
+	return func() {
+		os.Stdout.Close()
+		os.Stdout = stdout
+	}
+}
@@ -0,0 +1,185 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// Formats supported by WriteEffectiveConfig
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// The origin of a flag value.
+type Source int
+
+const (
+	SourceDefault Source = iota
+	SourceEnvironment
+	SourceConfig
+	SourceCommandLine
+)
+
+var (
+	sourceNames = []string{"default", "environment", "config", "command-line"}
+)
+
+// Describes where the value of a flag came from.
+type Provenance struct {
+	Source Source
+	// Environment variable of the value (SourceEnvironment)
+	Env string
+	// Configuration file of the value (SourceConfig)
+	File string
+	// Line within the configuration file (SourceConfig)
+	Line int
+	// Position of the argument on the command-line
+	// (SourceCommandLine). It is -1 for other sources.
+	Index int
+}
+
+// A flag as written by WriteEffectiveConfig.
+type effectiveFlag struct {
+	Name    string `json:"name"`
+	Command string `json:"command,omitempty"`
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+	Env     string `json:"env,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Index   *int   `json:"index,omitempty"`
+
+	origin Provenance
+}
+
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+
+	return sourceNames[s]
+}
+
+func (p Provenance) String() string {
+	switch p.Source {
+	case SourceEnvironment:
+		return fmt.Sprintf("%s (%s)", p.Source, p.Env)
+	case SourceConfig:
+		return fmt.Sprintf("%s (%s:%d)", p.Source, p.File, p.Line)
+	case SourceCommandLine:
+		return fmt.Sprintf("%s (argument %d)", p.Source, p.Index)
+	}
+
+	return p.Source.String()
+}
+
+// Find the origin of the value of a flag. The lookup considers the
+// same flags as Lookup. The second return value is false if there
+// is no such flag.
+func (p *Parser) Provenance(name string) (Provenance, bool) {
+	if flag := p.scope(p.triggered())(name); nil != flag {
+		return flag.origin, true
+	}
+
+	return defaultProvenance(), false
+}
+
+// Write the flags of the application and the triggered commands
+// along with their values and origins, either as aligned text or as
+// JSON array (see FormatText and FormatJSON). Flags shadowed by
+// those of a nested command are omitted. Flags of commands are
+// prefixed with the command path, e.g. "test.jobs".
+func (p *Parser) WriteEffectiveConfig(out io.Writer, format string) error {
+	flags := p.effectiveFlags()
+
+	switch format {
+	case FormatText:
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+		for _, flag := range flags {
+			name := flag.Name
+
+			if len(flag.Command) > 0 {
+				name = flag.Command + configSectionSep + name
+			}
+
+			fmt.Fprintf(table, "%s\t%s\t%s\n", name, flag.Value, flag.origin)
+		}
+
+		return table.Flush()
+	case FormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(flags)
+	}
+
+	return fmt.Errorf("Unsupported format '%s'", format)
+}
+
+func (p *Parser) effectiveFlags() []effectiveFlag {
+	levels := []*Command{nil}
+	flags := []effectiveFlag{}
+
+	if cmd := p.triggered(); nil != cmd {
+		levels = append(levels, cmd.path()...)
+	}
+
+	for index, level := range levels {
+		order, haystack, path := p.flagOrder, p.flags, []string{}
+
+		if nil != level {
+			order, haystack = level.flagOrder, level.flags
+
+			for _, cmd := range level.path() {
+				path = append(path, cmd.name)
+			}
+		}
+
+		for _, name := range order {
+			if shadowed(name, levels[index+1:]) {
+				continue
+			}
+
+			flags = append(flags, newEffectiveFlag(name, strings.Join(path, configSectionSep), haystack[name]))
+		}
+	}
+
+	return flags
+}
+
+func newEffectiveFlag(name string, cmd string, flag *Flag) effectiveFlag {
+	value := ""
+	origin := flag.origin
+
+	if g, ok := flag.value.(Getter); ok {
+		value = g.String()
+	}
+
+	entry := effectiveFlag{name, cmd, value, origin.Source.String(), origin.Env, origin.File, origin.Line, nil, origin}
+
+	if origin.Source == SourceCommandLine {
+		entry.Index = &origin.Index
+	}
+
+	return entry
+}
+
+// Whether a flag is hidden by a flag of one of the commands.
+func shadowed(name string, cmds []*Command) bool {
+	for _, cmd := range cmds {
+		if _, ok := cmd.flags[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func defaultProvenance() Provenance {
+	return Provenance{SourceDefault, "", "", 0, -1}
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"assert"
+)
+
+func TestProvenance(t *testing.T) {
+	unit, file := newConfigTestUnit(t, "app.ini", iniTestConfig)
+
+	err := unit.parser.ParseArgs([]string{"-config=" + file, "test", "nested", "-jobs=8"})
+
+	assert.True(t, "error", nil == err)
+
+	var tests = []struct {
+		name     string
+		expected Provenance
+		text     string
+	}{
+		{"jobs", Provenance{SourceCommandLine, "", "", 0, 3}, "command-line (argument 3)"},
+		{"level", Provenance{SourceEnvironment, "LEVEL", "", 0, -1}, "environment (LEVEL)"},
+		{"mode", Provenance{SourceConfig, "", file, 7, -1}, fmt.Sprintf("config (%s:7)", file)},
+		{"depth", Provenance{SourceConfig, "", file, 11, -1}, fmt.Sprintf("config (%s:11)", file)},
+		{"help", Provenance{SourceDefault, "", "", 0, -1}, "default"},
+	}
+
+	unit.parser.Flag("help", "test flag").Bool(false)
+
+	for _, test := range tests {
+		origin, ok := unit.parser.Provenance(test.name)
+
+		assert.True(t, test.name, ok)
+		assert.Equals(t, test.name, origin, test.expected)
+		assert.Equals(t, test.name, origin.String(), test.text)
+	}
+
+	_, ok := unit.parser.Provenance("name")
+
+	assert.False(t, "not in scope", ok)
+
+	unit.parser.ParseArgs([]string{})
+	origin, _ := unit.parser.Provenance("jobs")
+
+	assert.Equals(t, "reparsed", origin, Provenance{SourceConfig, "", file, 2, -1})
+}
+
+func TestProvenanceRejected(t *testing.T) {
+	unit, file := newConfigTestUnit(t, "app.ini", iniTestConfig)
+
+	unit.parser.CollectErrors(true)
+
+	err := unit.parser.ParseArgs([]string{"-config=" + file, "test", "-mode=bogus"})
+	origin, _ := unit.parser.Provenance("mode")
+
+	assert.True(t, "error", nil != err)
+	assert.Equals(t, "command-line", origin, Provenance{SourceConfig, "", file, 7, -1})
+
+	unit, file = newConfigTestUnit(t, "app.ini", "jobs = many\n")
+
+	err = unit.parser.ParseArgs([]string{"-config=" + file})
+	origin, _ = unit.parser.Provenance("jobs")
+
+	assert.True(t, "error", nil != err)
+	assert.Equals(t, "config", origin, defaultProvenance())
+	assert.False(t, "present", unit.parser.flags["jobs"].present)
+}
+
+func TestProvenanceMissingValue(t *testing.T) {
+	unit := NewParser("testing", false)
+
+	unit.Flag("jobs", "test flag").Value("NUM", true).Required().Int(1)
+
+	err := unit.ParseArgs([]string{"-jobs"})
+	origin, _ := unit.Provenance("jobs")
+
+	assert.True(t, "error", errors.Is(err, ErrMissingValue))
+	assert.False(t, "missing required", errors.Is(err, ErrMissingRequired))
+	assert.Equals(t, "origin", origin, defaultProvenance())
+	assert.False(t, "present", unit.flags["jobs"].present)
+}
+
+func TestWriteEffectiveConfig(t *testing.T) {
+	var out bytes.Buffer
+	var flags []map[string]interface{}
+
+	unit := NewParser("testing", false)
+	cmd := unit.Command("test", "test command")
+
+	unit.Flag("jobs", "test flag").Int(1)
+	unit.Flag("mode", "test flag").Enum("fast", "fast", "safe")
+	cmd.Flag("mode", "test flag").Enum("safe", "fast", "safe")
+	cmd.Flag("force", "test flag").Bool(false)
+
+	unit.ParseArgs([]string{"-jobs=4", "test", "-force"})
+
+	assert.True(t, "text", nil == unit.WriteEffectiveConfig(&out, FormatText))
+	assert.Equals(t, "text output", out.String(), ""+
+		"jobs        4     command-line (argument 0)\n"+
+		"test.mode   safe  default\n"+
+		"test.force  true  command-line (argument 2)\n")
+
+	out.Reset()
+
+	assert.True(t, "json", nil == unit.WriteEffectiveConfig(&out, FormatJSON))
+	assert.True(t, "json output", nil == json.Unmarshal(out.Bytes(), &flags))
+	assert.Equals(t, "json flags", len(flags), 3)
+	assert.Equals(t, "json flag", flags[1], map[string]interface{}{
+		"name": "mode", "command": "test", "value": "safe", "source": "default"})
+	assert.Equals(t, "json index", flags[2]["index"], float64(2))
+
+	err := unit.WriteEffectiveConfig(&out, "yaml")
+
+	assert.Equals(t, "format", err.Error(), "Unsupported format 'yaml'")
+}
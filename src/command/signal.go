@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const (
+	// exit code of a process terminated by a signal is this base
+	// plus the signal number, as reported by shells
+	signalExitBase = 128
+)
+
+// Derive a context which is cancelled on the first interrupt or
+// termination signal. The second signal exits the process. The
+// returned function stops the signal handling.
+func (p *Parser) notifySignals(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			p.exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// The exit code reported for a process terminated by the signal, e.g.
+// 130 for SIGINT and 143 for SIGTERM.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return signalExitBase + int(s)
+	}
+
+	return signalExitBase + int(syscall.SIGINT)
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"assert"
+)
+
+func TestCancelOnSignal(t *testing.T) {
+	unit := NewParser("testing", false)
+	exited := make(chan int, 1)
+
+	unit.exit = func(code int) {
+		exited <- code
+	}
+
+	unit.CancelOnSignal(true)
+	unit.Action(func(ctx *Context) error {
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+
+		select {
+		case <-ctx.Context().Done():
+		case <-time.After(5 * time.Second):
+			return errors.New("context not cancelled")
+		}
+
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+
+		select {
+		case code := <-exited:
+			assert.Equals(t, "exit code", code, 143)
+		case <-time.After(5 * time.Second):
+			return errors.New("process not terminated")
+		}
+
+		return ctx.Context().Err()
+	})
+
+	assert.Equals(t, "cancelled", unit.Run([]string{}), context.Canceled)
+}
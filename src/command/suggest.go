@@ -0,0 +1,132 @@
+package command
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// default edit distance for suggestions
+	suggestDistance = 2
+	// maximum number of suggestions per error
+	suggestLimit = 3
+	// minimum length of a prefix to count as match
+	suggestPrefix = 3
+)
+
+type suggestion struct {
+	name  string
+	score int
+}
+
+// Find the candidates similar to the input. Candidates differing only
+// in case score best, followed by prefix matches and candidates within
+// the given edit distance. Prefixes shorter than suggestPrefix do not
+// count. The result is ordered by similarity.
+func suggest(input string, candidates []string, distance int) []string {
+	matches := []suggestion{}
+	seen := make(map[string]bool)
+
+	if distance <= 0 || len(input) == 0 {
+		return []string{}
+	}
+
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+
+		seen[candidate] = true
+		score := levenshtein(input, candidate)
+
+		if strings.EqualFold(input, candidate) {
+			score = 0
+		} else if isPrefix(input, candidate) || isPrefix(candidate, input) {
+			score = 1
+		}
+
+		if score <= distance {
+			matches = append(matches, suggestion{candidate, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score == matches[j].score {
+			return matches[i].name < matches[j].name
+		}
+
+		return matches[i].score < matches[j].score
+	})
+
+	names := []string{}
+
+	for index := 0; index < len(matches) && index < suggestLimit; index++ {
+		names = append(names, matches[index].name)
+	}
+
+	return names
+}
+
+// Whether prefix is a sufficiently long prefix of text.
+func isPrefix(prefix string, text string) bool {
+	return len([]rune(prefix)) >= suggestPrefix && strings.HasPrefix(text, prefix)
+}
+
+// Compute the edit distance between two strings.
+func levenshtein(a string, b string) int {
+	source := []rune(a)
+	target := []rune(b)
+	row := make([]int, len(target)+1)
+
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		diagonal := row[0]
+		row[0] = i
+
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+
+			next := minInt(minInt(row[j]+1, row[j-1]+1), diagonal+cost)
+			diagonal = row[j]
+			row[j] = next
+		}
+	}
+
+	return row[len(target)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Format the suggestions as sentence, e.g. "Did you mean 'a' or 'b'?"
+func formatSuggestions(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(names))
+
+	for index, name := range names {
+		quoted[index] = "'" + name + "'"
+	}
+
+	last := len(quoted) - 1
+
+	if last == 0 {
+		return ". Did you mean " + quoted[0] + "?"
+	}
+
+	return ". Did you mean " + strings.Join(quoted[:last], ", ") + " or " + quoted[last] + "?"
+}
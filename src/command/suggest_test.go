@@ -0,0 +1,62 @@
+package command
+
+import (
+	"testing"
+
+	"assert"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"verbose", "version", "Test", "test", "jobs", "config", "conf", "v"}
+
+	var tests = []struct {
+		input    string
+		expected []string
+	}{
+		{"verbos", []string{"verbose"}},
+		{"vers", []string{"version"}},
+		{"tset", []string{"test"}},
+		{"TEST", []string{"Test", "test"}},
+		{"confi", []string{"conf", "config"}},
+		{"xyz", []string{}},
+		{"", []string{}},
+		{"vrebose-level", []string{}},
+		{"ve", []string{"v"}},
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, "suggestions", suggest(test.input, candidates, 2), test.expected)
+	}
+
+	assert.StringArrayEquals(t, "disabled", suggest("verbos", candidates, 0), []string{})
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equals(t, "equal", levenshtein("test", "test"), 0)
+	assert.Equals(t, "transposition", levenshtein("test", "tset"), 2)
+	assert.Equals(t, "insertion", levenshtein("verbos", "verbose"), 1)
+	assert.Equals(t, "empty", levenshtein("", "jobs"), 4)
+}
+
+func TestSuggestionErrors(t *testing.T) {
+	unit := NewParser("testing", false)
+	cmd := unit.Command("test", "test command")
+
+	unit.Command("remote", "test command")
+	unit.Flag("verbose", "test flag").Bool(false)
+	cmd.Flag("jobs", "test flag").Int(1)
+
+	err := unit.ParseArgs([]string{"tset"})
+
+	assert.Equals(t, "command", err.Error(), "No such command 'tset'. Did you mean 'test'?")
+
+	err = unit.ParseArgs([]string{"test", "-job=2"})
+
+	assert.Equals(t, "flag", err.Error(), "No such flag 'job'. Did you mean 'jobs'?")
+
+	unit.SuggestionDistance(0)
+
+	err = unit.ParseArgs([]string{"tset"})
+
+	assert.Equals(t, "disabled", err.Error(), "No such command 'tset'")
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package command
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// ioctl(2) terminal size structure
+type winsize struct {
+	rows   uint16
+	cols   uint16
+	xpixel uint16
+	ypixel uint16
+}
+
+// Query the number of columns of the terminal referred to by the
+// file descriptor. The second return value is false if the file
+// descriptor does not refer to a terminal.
+func terminalWidth(fd uintptr) (int, bool) {
+	var size winsize
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
+		fd,
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&size)))
+
+	if 0 != errno || 0 == size.cols {
+		return 0, false
+	}
+
+	return int(size.cols), true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package command
+
+// Terminal detection is only supported on Linux.
+func terminalWidth(fd uintptr) (int, bool) {
+	return 0, false
+}
@@ -3,59 +3,97 @@ package command
 import (
 	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 const (
 	formatUsage        = "[FLAG]... [COMMAND] [FLAG]..."
-	formatColumn       = "%-31s %s\n"
+	formatColumn       = "%-*s%s"
+	formatGap          = 2
+	formatWidth        = 80
 	formatIndent       = "    "
+	formatDefault      = "(default: %s)"
+	formatEnv          = "(env: %s)"
+	formatChoices      = "(choices: %s)"
+	formatRequired     = "(required)"
+	formatRepeatable   = "(repeatable)"
 	formatFlagBool     = flagPrefix + "%s"
 	formatFlagRequired = flagPrefix + "%s" + flagValueSep + "%s"
 	formatFlagOptional = flagPrefix + "%s" + flagValueSep + "[%s]"
+
+	formatGNUShort    = flagPrefix + "%c, "
+	formatGNUNoShort  = "    "
+	formatGNUBool     = flagLongPrefix + "%s"
+	formatGNURequired = flagLongPrefix + "%s" + flagValueSep + "%s"
+	formatGNUOptional = flagLongPrefix + "%s[" + flagValueSep + "%s]"
+
+	// environment variable holding the terminal width
+	envColumns = "COLUMNS"
 )
 
+// The usage message is buffered until it is flushed, as the width
+// of the first column depends on the longest flag or command.
 type usageWriter struct {
-	out io.Writer
+	out   io.Writer
+	gnu   bool
+	width int
+	rows  []usageRow
+}
+
+// A single line of text or a two-column entry, whose second column
+// consists of unwrapped paragraphs.
+type usageRow struct {
+	text    string
+	columns bool
+	texts   []string
 }
 
 // Write the command usage pattern.
 func (u *usageWriter) WriteTitle(application string) *usageWriter {
-	fmt.Fprintln(u.out, "Usage:", application, formatUsage)
-
-	return u
+	return u.writeLine(fmt.Sprint("Usage: ", application, " ", formatUsage))
 }
 
-// Describe a single commandline flag.
-func (u *usageWriter) WriteFlag(name string, flag *Flag, indent bool) *usageWriter {
+// Describe a single commandline flag. The depth denotes the
+// indentation level.
+func (u *usageWriter) WriteFlag(name string, flag *Flag, depth int) *usageWriter {
 	prefix := formatFlag(name, flag.valueName, flag.valueReq, flag.value)
 
-	if indent {
-		prefix = formatIndent + prefix
+	if u.gnu {
+		prefix = formatGNUFlag(name, flag.short, flag.valueName, flag.valueReq, flag.value)
 	}
 
-	fmt.Fprintf(u.out, formatColumn, prefix, flag.desc)
+	texts := append([]string{flag.desc}, formatNotes(flag)...)
 
-	return u
+	return u.writeColumns(formatDepth(prefix, depth), texts...)
 }
 
-// Describe a command.
-func (u *usageWriter) WriteCommand(name string, cmd *Command) *usageWriter {
-	fmt.Fprintf(u.out, formatColumn, name, cmd.desc)
+// Describe a command. Nested commands are indented according to
+// their depth in the command tree.
+func (u *usageWriter) WriteCommand(name string, cmd *Command, depth int) *usageWriter {
+	return u.writeColumns(formatDepth(name, depth), cmd.desc)
+}
 
-	return u
+// Write the heading of a flag group or command category.
+func (u *usageWriter) WriteGroup(name string, depth int) *usageWriter {
+	u.writeLine("")
+
+	return u.writeLine(formatDepth(name+":", depth))
 }
 
 // Write the command/flag description header. The command header
 // has three columns streched over two lines, whereas the flag header
 // uses only a single line.
 func (u *usageWriter) WriteHeader(commandHeader bool) *usageWriter {
-	fmt.Fprintln(u.out)
+	u.writeLine("")
 
 	if commandHeader {
-		fmt.Fprintf(u.out, formatColumn, "Command", "Meaning")
-		fmt.Fprintln(u.out, formatIndent+"Option")
+		u.writeColumns("Command", "Meaning")
+		u.writeLine(formatIndent + "Option")
 	} else {
-		fmt.Fprintf(u.out, formatColumn, "Option", "Meaning")
+		u.writeColumns("Option", "Meaning")
 	}
 
 	return u
@@ -63,14 +101,100 @@ func (u *usageWriter) WriteHeader(commandHeader bool) *usageWriter {
 
 // Write the usage footer message.
 func (u *usageWriter) WriteFooter() *usageWriter {
-	fmt.Fprintln(u.out)
-	fmt.Fprintln(u.out, "Flag processing can be terminated using", flagTermination)
+	u.writeLine("")
+
+	return u.writeLine(fmt.Sprint("Flag processing can be terminated using ", flagTermination))
+}
+
+// Write the buffered usage message. The first column is as wide as
+// the longest entry, but at most half of the total width. Entries
+// exceeding it are placed on a line of their own.
+func (u *usageWriter) Flush() {
+	column := u.column()
+
+	for _, row := range u.rows {
+		if false == row.columns {
+			fmt.Fprintln(u.out, row.text)
+			continue
+		}
+
+		prefix := row.text
+		lines := []string{}
+
+		for _, text := range row.texts {
+			lines = append(lines, formatText(text, u.width-column)...)
+		}
+
+		if len(prefix) >= column {
+			fmt.Fprintln(u.out, prefix)
+			prefix = ""
+		}
+
+		for _, line := range lines {
+			fmt.Fprintln(u.out, strings.TrimRight(fmt.Sprintf(formatColumn, column, prefix, line), " "))
+			prefix = ""
+		}
+	}
+
+	u.rows = nil
+}
+
+func (u *usageWriter) writeLine(text string) *usageWriter {
+	u.rows = append(u.rows, usageRow{text, false, nil})
 
 	return u
 }
 
-func newUsageWriter(writer io.Writer) *usageWriter {
-	return &usageWriter{writer}
+func (u *usageWriter) writeColumns(prefix string, texts ...string) *usageWriter {
+	u.rows = append(u.rows, usageRow{prefix, true, texts})
+
+	return u
+}
+
+// Compute the width of the first column.
+func (u *usageWriter) column() int {
+	column := 0
+
+	for _, row := range u.rows {
+		if row.columns && len(row.text)+formatGap > column {
+			column = len(row.text) + formatGap
+		}
+	}
+
+	if column > u.width/2 {
+		column = u.width / 2
+	}
+
+	return column
+}
+
+// Create a writer wrapping lines at the given width. If the width
+// is not positive, it is detected (see detectWidth).
+func newUsageWriter(writer io.Writer, gnu bool, width int) *usageWriter {
+	if width <= 0 {
+		width = formatWidth
+	}
+
+	return &usageWriter{writer, gnu, width, nil}
+}
+
+// Determine the width of the output. The COLUMNS environment variable
+// takes precedence over the size of the terminal (if the writer is
+// one). Otherwise a default width of 80 is assumed.
+func detectWidth(out io.Writer, env func(string) (string, bool)) int {
+	if val, ok := env(envColumns); ok {
+		if width, err := strconv.Atoi(val); nil == err && width > 0 {
+			return width
+		}
+	}
+
+	if file, ok := out.(*os.File); ok {
+		if width, ok := terminalWidth(file.Fd()); ok {
+			return width
+		}
+	}
+
+	return formatWidth
 }
 
 func formatFlag(name string, key string, req bool, value interface{}) string {
@@ -82,3 +206,153 @@ func formatFlag(name string, key string, req bool, value interface{}) string {
 
 	return fmt.Sprintf(formatFlagOptional, name, key)
 }
+
+// Format a flag in GNU notation, e.g. "-j, --jobs=NUM". Flags
+// without a single character name are aligned with those having one.
+func formatGNUFlag(name string, short rune, key string, req bool, value interface{}) string {
+	prefix := formatGNUNoShort
+
+	if 0 != short {
+		prefix = fmt.Sprintf(formatGNUShort, short)
+	}
+
+	if b, ok := value.(inferableValue); ok && b.IsBoolFlag() {
+		return prefix + fmt.Sprintf(formatGNUBool, name)
+	} else if req {
+		return prefix + fmt.Sprintf(formatGNURequired, name, key)
+	}
+
+	return prefix + fmt.Sprintf(formatGNUOptional, name, key)
+}
+
+// Describe the default value, environment variable, choices and
+// presence requirement of a flag (if applicable).
+func formatNotes(flag *Flag) []string {
+	notes := []string{}
+
+	if len(flag.defValue) > 0 && false == isBoolFlag(flag.value) {
+		notes = append(notes, fmt.Sprintf(formatDefault, flag.defValue))
+	}
+
+	if len(flag.env) > 0 {
+		notes = append(notes, fmt.Sprintf(formatEnv, flag.env))
+	}
+
+	if c, ok := flag.value.(choiceValue); ok {
+		notes = append(notes, fmt.Sprintf(formatChoices, strings.Join(c.Choices(), ", ")))
+	}
+
+	if isRepeatable(flag.value) {
+		notes = append(notes, formatRepeatable)
+	}
+
+	if flag.required {
+		notes = append(notes, formatRequired)
+	}
+
+	return notes
+}
+
+// Break a description into lines. Paragraphs are separated by
+// blank lines and wrapped. Lines starting with whitespace are
+// considered preformatted (e.g. examples) and kept as they are.
+func formatText(text string, width int) []string {
+	lines := []string{}
+
+	for index, paragraph := range strings.Split(text, "\n\n") {
+		words := []string{}
+		start := len(lines)
+
+		if index > 0 {
+			lines = append(lines, "")
+			start++
+		}
+
+		for _, line := range strings.Split(paragraph, "\n") {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				if len(words) > 0 {
+					lines = append(lines, wrapText(strings.Join(words, " "), width)...)
+					words = words[:0]
+				}
+
+				lines = append(lines, strings.Replace(line, "\t", formatIndent, -1))
+			} else {
+				words = append(words, line)
+			}
+		}
+
+		if len(words) > 0 || len(lines) == start {
+			lines = append(lines, wrapText(strings.Join(words, " "), width)...)
+		}
+	}
+
+	return lines
+}
+
+// Break the text into lines not exceeding the given width. Words
+// longer than the width are not split. The result contains at
+// least one (possibly empty) line.
+func wrapText(text string, width int) []string {
+	lines := []string{}
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		if len(line) > 0 && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if len(line) > 0 {
+			line += " "
+		}
+
+		line += word
+	}
+
+	return append(lines, line)
+}
+
+func formatDepth(text string, depth int) string {
+	return strings.Repeat(formatIndent, depth) + text
+}
+
+// Append a name to the registration order unless it has been
+// registered before.
+func register(order []string, name string, known bool) []string {
+	if known {
+		return order
+	}
+
+	return append(order, name)
+}
+
+// Partition the names by their group. The groups are returned in
+// order of their first occurrence, with the unnamed group first.
+// Optionally names and groups are sorted alphabetically instead.
+func groupNames(order []string, alphabetical bool, groupOf func(string) string) ([]string, map[string][]string) {
+	names := order
+	groups := []string{""}
+	members := make(map[string][]string)
+
+	if alphabetical {
+		names = append([]string{}, order...)
+
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		group := groupOf(name)
+
+		if _, ok := members[group]; false == ok && len(group) > 0 {
+			groups = append(groups, group)
+		}
+
+		members[group] = append(members[group], name)
+	}
+
+	if alphabetical {
+		sort.Strings(groups[1:])
+	}
+
+	return groups, members
+}
@@ -0,0 +1,232 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"assert"
+)
+
+func TestWriteUsageNested(t *testing.T) {
+	var out bytes.Buffer
+	unit := NewParser("testing", false)
+	remote := unit.Command("remote", "test command")
+	add := remote.Command("add", "nested test command")
+
+	add.Flag("fetch", "test flag").Bool(false)
+
+	unit.WriteUsage(&out)
+
+	lines := strings.Split(out.String(), "\n")
+
+	assert.True(t, "command", containsLine(lines, "remote"))
+	assert.True(t, "nested command", containsLine(lines, formatIndent+"add"))
+	assert.True(t, "nested flag", containsLine(lines, formatIndent+formatIndent+"-fetch"))
+}
+
+func containsLine(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestWriteUsageOrder(t *testing.T) {
+	var out bytes.Buffer
+	unit := newUsageTestUnit()
+
+	unit.WriteUsage(&out)
+
+	expected := `Usage: testing [FLAG]... [COMMAND] [FLAG]...
+
+Option           Meaning
+-verbose         test flag
+-help            test flag
+
+Network:
+-proxy=[VAL]     test flag
+                 (default: 0)
+-insecure        test flag
+
+Command          Meaning
+    Option
+test             test command
+    -jobs=[VAL]  test flag
+                 (default: 1)
+    -fail        test flag
+
+Remote:
+remote           test command
+    add          nested test command
+fetch            test command
+
+Flag processing can be terminated using --
+`
+
+	assert.Equals(t, "usage", out.String(), expected)
+}
+
+func TestWriteUsageSorted(t *testing.T) {
+	var out bytes.Buffer
+	unit := newUsageTestUnit()
+
+	unit.SortUsage(true)
+	unit.WriteUsage(&out)
+
+	expected := `Usage: testing [FLAG]... [COMMAND] [FLAG]...
+
+Option           Meaning
+-help            test flag
+-verbose         test flag
+
+Network:
+-insecure        test flag
+-proxy=[VAL]     test flag
+                 (default: 0)
+
+Command          Meaning
+    Option
+test             test command
+    -fail        test flag
+    -jobs=[VAL]  test flag
+                 (default: 1)
+
+Remote:
+fetch            test command
+remote           test command
+    add          nested test command
+
+Flag processing can be terminated using --
+`
+
+	assert.Equals(t, "usage", out.String(), expected)
+}
+
+func newUsageTestUnit() *Parser {
+	unit := NewParser("testing", false)
+
+	unit.UsageWidth(80)
+
+	unit.Flag("verbose", "test flag").Bool(false)
+	unit.Flag("proxy", "test flag").Group("Network").Int(0)
+	unit.Flag("help", "test flag").Bool(false)
+	unit.Flag("insecure", "test flag").Group("Network").Bool(false)
+
+	test := unit.Command("test", "test command")
+	remote := unit.Command("remote", "test command").Category("Remote")
+	unit.Command("fetch", "test command").Category("Remote")
+
+	test.Flag("jobs", "test flag").Int(1)
+	test.Flag("fail", "test flag").Bool(false)
+	remote.Command("add", "nested test command")
+
+	return unit
+}
+
+func TestWriteFlagNotes(t *testing.T) {
+	var out bytes.Buffer
+	unit := NewParser("testing", false)
+
+	unit.Flag("mode", "Select the operation mode of the application, which affects all commands").
+		EnvironmentValue("MODE").
+		Required().
+		Enum("fast", "fast", "safe")
+
+	unit.UsageWidth(50)
+	unit.WriteUsage(&out)
+
+	expected := `
+Option       Meaning
+-mode=[VAL]  Select the operation mode of the
+             application, which affects all
+             commands
+             (default: fast)
+             (env: MODE)
+             (choices: fast, safe)
+             (required)
+`
+
+	assert.True(t, "flag notes", strings.Contains(out.String(), expected))
+}
+
+func TestWriteFlagNotesRepeatable(t *testing.T) {
+	var out bytes.Buffer
+	unit := NewParser("testing", false)
+
+	unit.Flag("tag", "Tag the result").Value("TAG", true).Strings("latest")
+
+	unit.WriteUsage(&out)
+
+	expected := `
+Option    Meaning
+-tag=TAG  Tag the result
+          (default: latest)
+          (repeatable)
+`
+
+	assert.True(t, "flag notes", strings.Contains(out.String(), expected))
+}
+
+func TestWriteUsageWidth(t *testing.T) {
+	var out bytes.Buffer
+	unit := NewParser("testing", false)
+	env := map[string]string{"COLUMNS": "40"}
+
+	unit.Flag("config", "Use FILE as configuration source.\n\nExample:\n\tapp -config=~/.apprc").
+		Value("FILE", true).
+		Void(false)
+	unit.Flag("a-very-long-flag-name-exceeding-the-column", "Ignored").
+		Void(true)
+	unit.Environment(newEnvironment(env))
+	unit.WriteUsage(&out)
+
+	expected := `
+Option              Meaning
+-config=FILE        Use FILE as
+                    configuration
+                    source.
+
+                    Example:
+                        app -config=~/.apprc
+-a-very-long-flag-name-exceeding-the-column
+                    Ignored
+`
+
+	assert.True(t, "usage width", strings.Contains(out.String(), expected))
+}
+
+func TestFormatText(t *testing.T) {
+	var tests = []struct {
+		text     string
+		expected []string
+	}{
+		{"", []string{""}},
+		{"one two three", []string{"one two", "three"}},
+		{"one\ntwo\n\nthree", []string{"one two", "", "three"}},
+		{"one:\n  two  three\nfour", []string{"one:", "  two  three", "four"}},
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, "lines", formatText(test.text, 7), test.expected)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	var tests = []struct {
+		text     string
+		expected []string
+	}{
+		{"", []string{""}},
+		{"one two three", []string{"one two", "three"}},
+		{"one  two\nthree", []string{"one two", "three"}},
+		{"overlong words", []string{"overlong", "words"}},
+	}
+
+	for _, test := range tests {
+		assert.StringArrayEquals(t, "lines", wrapText(test.text, 7), test.expected)
+	}
+}
@@ -1,22 +1,53 @@
 package command
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	homePrefix = "~"
+	listSep    = ","
+	mapKeySep  = "="
 )
 
 var (
 	// string notation lookup table for boolean values
 	booleans map[string]bool
+	// units accepted by byte size values, largest first
+	byteUnits = []byteUnit{
+		{"PiB", 1 << 50}, {"PB", 1e15},
+		{"TiB", 1 << 40}, {"TB", 1e12},
+		{"GiB", 1 << 30}, {"GB", 1e9},
+		{"MiB", 1 << 20}, {"MB", 1e6},
+		{"KiB", 1 << 10}, {"KB", 1e3},
+		{"B", 1},
+	}
 )
 
 type Value interface {
-	//Get() interface{}
 	Set(string) error
 }
 
+// Values which are able to report their current state. All
+// built-in values implement this interface.
+type Getter interface {
+	Value
+	Get() interface{}
+	String() string
+}
+
 // Boolean values can be infered by the presence of
 // the flag on the command-line
 type inferableValue interface {
@@ -27,15 +58,128 @@ type boolValue struct {
 	out *bool
 }
 
+// Values which are written with an empty string if their flag is
+// given without its optional value (e.g. counters). Other values
+// keep their state in that case.
+type emptyValue interface {
+	AcceptsEmpty() bool
+}
+
+// Values which accept a fixed set of inputs
+type choiceValue interface {
+	Choices() []string
+}
+
+type enumValue struct {
+	out     *string
+	choices []string
+}
+
 type fileValue struct {
-	out *string
-	dir bool
+	out    *string
+	dir    bool
+	checks FileCheck
 }
 
 type intValue struct {
 	out *int
 }
 
+// Values which collect every occurrence of their flag instead of
+// keeping the last one.
+type repeatableValue interface {
+	IsRepeatable() bool
+}
+
+// Values which restore their initial state before each parse.
+type resettableValue interface {
+	reset()
+}
+
+// The first occurrence replaces the default elements. If split points
+// to a non-empty separator, each occurrence may provide several
+// elements (see Flag.Split).
+type stringsValue struct {
+	out      *[]string
+	split    *string
+	defaults []string
+	fresh    bool
+}
+
+type intsValue struct {
+	out      *[]int
+	split    *string
+	defaults []int
+	fresh    bool
+}
+
+// Collects key=value pairs.
+type stringMapValue struct {
+	out      map[string]string
+	split    *string
+	defaults map[string]string
+	fresh    bool
+}
+
+type stringValue struct {
+	out *string
+}
+
+type float64Value struct {
+	out *float64
+}
+
+type int64Value struct {
+	out *int64
+}
+
+type uint64Value struct {
+	out *uint64
+}
+
+type durationValue struct {
+	out *time.Duration
+}
+
+type timeValue struct {
+	out    *time.Time
+	layout string
+}
+
+// A number of bytes with an optional unit, e.g. 10MiB or 1.5GB.
+type byteSizeValue struct {
+	out *uint64
+}
+
+type byteUnit struct {
+	name string
+	size uint64
+}
+
+type urlValue struct {
+	out *url.URL
+}
+
+type ipValue struct {
+	out *net.IP
+}
+
+type ipNetValue struct {
+	out *net.IPNet
+}
+
+type regexpValue struct {
+	out **regexp.Regexp
+}
+
+type hexValue struct {
+	out *[]byte
+}
+
+type base64Value struct {
+	out *[]byte
+}
+
 type voidValue struct {
 	emulateBool bool
 }
@@ -50,37 +194,638 @@ func (b *boolValue) Set(value string) error {
 	return fmt.Errorf("'%s' is not a valid boolean value.", value)
 }
 
+func (b *boolValue) Get() interface{} {
+	if nil == b.out {
+		return false
+	}
+
+	return *(b.out)
+}
+
+func (b *boolValue) String() string {
+	return strconv.FormatBool(b.Get().(bool))
+}
+
 func (b *boolValue) IsBoolFlag() bool {
 	return true
 }
 
-func (f *fileValue) Set(value string) (err error) {
-	if out, err := filepath.Abs(value); nil == err {
-		if info, err := os.Stat(out); nil == err {
-			// arrow-pattern alert!
-			err = setFileValue(f.out, info, f.dir)
+func (e *enumValue) Set(value string) error {
+	for _, choice := range e.choices {
+		if choice == value {
+			*(e.out) = value
+
+			return nil
 		}
 	}
 
-	return
+	return fmt.Errorf("'%s' is not one of %s", value, strings.Join(e.choices, ", "))
+}
+
+func (e *enumValue) Get() interface{} {
+	if nil == e.out {
+		return ""
+	}
+
+	return *(e.out)
+}
+
+func (e *enumValue) String() string {
+	return e.Get().(string)
+}
+
+func (e *enumValue) Choices() []string {
+	return e.choices
+}
+
+func (f *fileValue) Set(value string) error {
+	out, err := expandPath(value)
+
+	if nil != err {
+		return err
+	}
+
+	if err = checkFile(out, f.dir, f.checks); nil != err {
+		return err
+	}
+
+	*(f.out) = out
+
+	return nil
+}
+
+func (f *fileValue) Get() interface{} {
+	if nil == f.out {
+		return ""
+	}
+
+	return *(f.out)
+}
+
+func (f *fileValue) String() string {
+	return f.Get().(string)
 }
 
-func (i *intValue) Set(value string) (err error) {
+func (i *intValue) Set(value string) error {
 	if out, err := strconv.Atoi(value); nil == err {
 		*(i.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid integer value.", value)
+}
+
+func (i *intValue) Get() interface{} {
+	if nil == i.out {
+		return 0
+	}
+
+	return *(i.out)
+}
+
+func (i *intValue) String() string {
+	return strconv.Itoa(i.Get().(int))
+}
+
+func (s *stringsValue) Set(value string) error {
+	if s.fresh {
+		*(s.out) = []string{}
+		s.fresh = false
+	}
+
+	*(s.out) = append(*(s.out), splitValue(value, s.split)...)
+
+	return nil
+}
+
+func (s *stringsValue) Get() interface{} {
+	if nil == s.out {
+		return []string{}
+	}
+
+	return *(s.out)
+}
+
+func (s *stringsValue) String() string {
+	return strings.Join(s.Get().([]string), listSep)
+}
+
+func (s *stringsValue) IsRepeatable() bool {
+	return true
+}
+
+func (s *stringsValue) reset() {
+	*(s.out) = append([]string{}, s.defaults...)
+	s.fresh = true
+}
+
+func (i *intsValue) Set(value string) error {
+	elems := splitValue(value, i.split)
+	out := make([]int, len(elems))
+
+	for index, elem := range elems {
+		n, err := strconv.Atoi(elem)
+
+		if nil != err {
+			return fmt.Errorf("'%s' is not a valid integer value.", elem)
+		}
+
+		out[index] = n
+	}
+
+	if i.fresh {
+		*(i.out) = []int{}
+		i.fresh = false
+	}
+
+	*(i.out) = append(*(i.out), out...)
+
+	return nil
+}
+
+func (i *intsValue) Get() interface{} {
+	if nil == i.out {
+		return []int{}
+	}
+
+	return *(i.out)
+}
+
+func (i *intsValue) String() string {
+	elems := []string{}
+
+	for _, n := range i.Get().([]int) {
+		elems = append(elems, strconv.Itoa(n))
+	}
+
+	return strings.Join(elems, listSep)
+}
+
+func (i *intsValue) IsRepeatable() bool {
+	return true
+}
+
+func (i *intsValue) reset() {
+	*(i.out) = append([]int{}, i.defaults...)
+	i.fresh = true
+}
+
+func (m *stringMapValue) Set(value string) error {
+	elems := splitValue(value, m.split)
+	keys := make([]string, len(elems))
+	values := make([]string, len(elems))
+
+	for index, elem := range elems {
+		key, val, ok := strings.Cut(elem, mapKeySep)
+
+		if false == ok || len(key) == 0 {
+			return fmt.Errorf("'%s' is not a valid key%svalue pair.", elem, mapKeySep)
+		}
+
+		keys[index] = key
+		values[index] = val
+	}
+
+	if m.fresh {
+		clearMap(m.out)
+		m.fresh = false
+	}
+
+	for index, key := range keys {
+		m.out[key] = values[index]
+	}
+
+	return nil
+}
+
+func (m *stringMapValue) Get() interface{} {
+	return m.out
+}
+
+func (m *stringMapValue) String() string {
+	pairs := []string{}
+
+	for key, val := range m.out {
+		pairs = append(pairs, key+mapKeySep+val)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, listSep)
+}
+
+func (m *stringMapValue) IsRepeatable() bool {
+	return true
+}
+
+func (m *stringMapValue) reset() {
+	clearMap(m.out)
+
+	for key, val := range m.defaults {
+		m.out[key] = val
+	}
+
+	m.fresh = true
+}
+
+func (s *stringValue) Set(value string) error {
+	*(s.out) = value
+
+	return nil
+}
+
+func (s *stringValue) Get() interface{} {
+	if nil == s.out {
+		return ""
+	}
+
+	return *(s.out)
+}
+
+func (s *stringValue) String() string {
+	return s.Get().(string)
+}
+
+func (f *float64Value) Set(value string) error {
+	if out, err := strconv.ParseFloat(value, 64); nil == err {
+		*(f.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid number.", value)
+}
+
+func (f *float64Value) Get() interface{} {
+	if nil == f.out {
+		return float64(0)
+	}
+
+	return *(f.out)
+}
+
+func (f *float64Value) String() string {
+	return strconv.FormatFloat(f.Get().(float64), 'g', -1, 64)
+}
+
+func (i *int64Value) Set(value string) error {
+	if out, err := strconv.ParseInt(value, 0, 64); nil == err {
+		*(i.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid integer value.", value)
+}
+
+func (i *int64Value) Get() interface{} {
+	if nil == i.out {
+		return int64(0)
+	}
+
+	return *(i.out)
+}
+
+func (i *int64Value) String() string {
+	return strconv.FormatInt(i.Get().(int64), 10)
+}
+
+func (u *uint64Value) Set(value string) error {
+	if out, err := strconv.ParseUint(value, 0, 64); nil == err {
+		*(u.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid unsigned integer value.", value)
+}
+
+func (u *uint64Value) Get() interface{} {
+	if nil == u.out {
+		return uint64(0)
+	}
+
+	return *(u.out)
+}
+
+func (u *uint64Value) String() string {
+	return strconv.FormatUint(u.Get().(uint64), 10)
+}
+
+func (d *durationValue) Set(value string) error {
+	if out, err := time.ParseDuration(value); nil == err {
+		*(d.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid duration.", value)
+}
+
+func (d *durationValue) Get() interface{} {
+	if nil == d.out {
+		return time.Duration(0)
+	}
+
+	return *(d.out)
+}
+
+func (d *durationValue) String() string {
+	return d.Get().(time.Duration).String()
+}
+
+func (t *timeValue) Set(value string) error {
+	if out, err := time.Parse(t.layout, value); nil == err {
+		*(t.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid time, expected %s", value, t.layout)
+}
+
+func (t *timeValue) Get() interface{} {
+	if nil == t.out {
+		return time.Time{}
+	}
+
+	return *(t.out)
+}
+
+// The zero time is not shown as default value.
+func (t *timeValue) String() string {
+	if out := t.Get().(time.Time); false == out.IsZero() {
+		return out.Format(t.layout)
+	}
+
+	return ""
+}
+
+func (b *byteSizeValue) Set(value string) error {
+	number := strings.TrimRightFunc(value, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	size, err := strconv.ParseFloat(number, 64)
+	unit := strings.TrimSpace(value[len(number):])
+
+	if nil == err && size >= 0 {
+		for _, candidate := range byteUnits {
+			if strings.EqualFold(unit, candidate.name) || (len(unit) == 0 && candidate.size == 1) {
+				size *= float64(candidate.size)
+
+				if size < math.MaxUint64 && size == math.Trunc(size) {
+					*(b.out) = uint64(size)
+
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("'%s' is not a valid size, e.g. 10MiB", value)
+}
+
+func (b *byteSizeValue) Get() interface{} {
+	if nil == b.out {
+		return uint64(0)
+	}
+
+	return *(b.out)
+}
+
+// Formats the size using the largest unit without a remainder.
+func (b *byteSizeValue) String() string {
+	size := b.Get().(uint64)
+
+	for _, unit := range byteUnits {
+		if size > 0 && size%unit.size == 0 {
+			return strconv.FormatUint(size/unit.size, 10) + unit.name
+		}
+	}
+
+	return "0B"
+}
+
+// Only absolute URLs (with a scheme) are accepted.
+func (u *urlValue) Set(value string) error {
+	if out, err := url.Parse(value); nil == err && len(out.Scheme) > 0 {
+		*(u.out) = *out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid URL.", value)
+}
+
+func (u *urlValue) Get() interface{} {
+	if nil == u.out {
+		return url.URL{}
+	}
+
+	return *(u.out)
+}
+
+func (u *urlValue) String() string {
+	out := u.Get().(url.URL)
+
+	return out.String()
+}
+
+func (i *ipValue) Set(value string) error {
+	if out := net.ParseIP(value); nil != out {
+		*(i.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid IP address.", value)
+}
+
+func (i *ipValue) Get() interface{} {
+	if nil == i.out {
+		return net.IP(nil)
 	}
 
-	return
+	return *(i.out)
+}
+
+func (i *ipValue) String() string {
+	if out := i.Get().(net.IP); nil != out {
+		return out.String()
+	}
+
+	return ""
+}
+
+func (i *ipNetValue) Set(value string) error {
+	if _, out, err := net.ParseCIDR(value); nil == err {
+		*(i.out) = *out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid network in CIDR notation.", value)
+}
+
+func (i *ipNetValue) Get() interface{} {
+	if nil == i.out {
+		return net.IPNet{}
+	}
+
+	return *(i.out)
+}
+
+func (i *ipNetValue) String() string {
+	if out := i.Get().(net.IPNet); nil != out.IP {
+		return out.String()
+	}
+
+	return ""
+}
+
+func (r *regexpValue) Set(value string) error {
+	out, err := regexp.Compile(value)
+
+	if nil != err {
+		return fmt.Errorf("'%s' is not a valid regular expression: %v", value, err)
+	}
+
+	*(r.out) = out
+
+	return nil
+}
+
+func (r *regexpValue) Get() interface{} {
+	if nil == r.out {
+		return (*regexp.Regexp)(nil)
+	}
+
+	return *(r.out)
+}
+
+func (r *regexpValue) String() string {
+	if out := r.Get().(*regexp.Regexp); nil != out {
+		return out.String()
+	}
+
+	return ""
+}
+
+func (h *hexValue) Set(value string) error {
+	if out, err := hex.DecodeString(value); nil == err {
+		*(h.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid hexadecimal value.", value)
+}
+
+func (h *hexValue) Get() interface{} {
+	if nil == h.out {
+		return []byte{}
+	}
+
+	return *(h.out)
+}
+
+func (h *hexValue) String() string {
+	return hex.EncodeToString(h.Get().([]byte))
+}
+
+// Accepts the standard encoding with or without padding.
+func (b *base64Value) Set(value string) error {
+	out, err := base64.StdEncoding.DecodeString(value)
+
+	if nil != err {
+		out, err = base64.RawStdEncoding.DecodeString(value)
+	}
+
+	if nil == err {
+		*(b.out) = out
+
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid base64 value.", value)
+}
+
+func (b *base64Value) Get() interface{} {
+	if nil == b.out {
+		return []byte{}
+	}
+
+	return *(b.out)
+}
+
+func (b *base64Value) String() string {
+	return base64.StdEncoding.EncodeToString(b.Get().([]byte))
 }
 
 func (v *voidValue) Set(value string) error {
 	return nil
 }
 
+func (v *voidValue) Get() interface{} {
+	return nil
+}
+
+func (v *voidValue) String() string {
+	return ""
+}
+
 func (v *voidValue) IsBoolFlag() bool {
 	return v.emulateBool
 }
 
+// Check whether the value can be infered by the presence
+// of its flag.
+func isBoolFlag(value Value) bool {
+	b, ok := value.(inferableValue)
+
+	return ok && b.IsBoolFlag()
+}
+
+// Check whether the value accepts an omitted optional value.
+func acceptsEmpty(value Value) bool {
+	e, ok := value.(emptyValue)
+
+	return ok && e.AcceptsEmpty()
+}
+
+// Check whether the value collects repeated occurrences of its flag.
+func isRepeatable(value Value) bool {
+	r, ok := value.(repeatableValue)
+
+	return ok && r.IsRepeatable()
+}
+
+func clearMap(out map[string]string) {
+	for key := range out {
+		delete(out, key)
+	}
+}
+
+// Split a value at the separator, if any.
+func splitValue(value string, sep *string) []string {
+	if nil == sep || len(*sep) == 0 {
+		return []string{value}
+	}
+
+	return strings.Split(value, *sep)
+}
+
+// Read the current state of a value. Values not implementing
+// the Getter interface yield nil.
+func getValue(value Value) interface{} {
+	if g, ok := value.(Getter); ok {
+		return g.Get()
+	}
+
+	return nil
+}
+
 func init() {
 	booleans = make(map[string]bool)
 
@@ -105,16 +850,21 @@ func init() {
 	booleans["False"] = false
 }
 
-func setFileValue(out *string, meta os.FileInfo, mustDir bool) error {
-	if meta.IsDir() {
-		if false == mustDir {
-			return fmt.Errorf("'%s' is a directory", meta.Name())
+// Expand a leading ~ to the home directory of the current user as
+// well as any environment variables ($VAR or ${VAR}) in the path.
+// The result is an absolute path.
+func expandPath(value string) (string, error) {
+	path := os.ExpandEnv(value)
+
+	if path == homePrefix || strings.HasPrefix(path, homePrefix+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+
+		if nil != err {
+			return "", err
 		}
-	} else if mustDir {
-		return fmt.Errorf("'%s' is not a directory", meta.Name())
-	}
 
-	*out = meta.Name()
+		path = filepath.Join(home, path[len(homePrefix):])
+	}
 
-	return nil
+	return filepath.Abs(path)
 }
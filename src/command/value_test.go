@@ -1,7 +1,14 @@
 package command
 
 import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 type booleanTest struct {
@@ -50,3 +57,286 @@ func TestBooleanValue(t *testing.T) {
 		}
 	}
 }
+
+func TestGetter(t *testing.T) {
+	b := true
+	i := 42
+	f := "/etc/app/rc"
+
+	var tests = []struct {
+		unit   Getter
+		value  interface{}
+		string string
+	}{
+		{&boolValue{&b}, true, "true"},
+		{&intValue{&i}, 42, "42"},
+		{&fileValue{&f, false, FileExists}, "/etc/app/rc", "/etc/app/rc"},
+		{&voidValue{true}, nil, ""},
+	}
+
+	for _, test := range tests {
+		if actual := test.unit.Get(); actual != test.value {
+			t.Error("value mismatch. expected:", test.value, "got:", actual)
+		}
+
+		if actual := test.unit.String(); actual != test.string {
+			t.Error("string mismatch. expected:", test.string, "got:", actual)
+		}
+	}
+}
+
+func TestFileValue(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, "rc")
+	missing := filepath.Join(base, "missing")
+
+	if err := os.WriteFile(file, []byte{}, 0600); nil != err {
+		t.Fatal("unable to create test file:", err)
+	}
+
+	t.Setenv("TEST_BASE", base)
+	t.Setenv("HOME", base)
+
+	var success = []struct {
+		input    string
+		dir      bool
+		checks   FileCheck
+		expected string
+	}{
+		{file, false, FileExists, file},
+		{"$TEST_BASE/rc", false, FileExists, file},
+		{"~/rc", false, FileExists | FileReadable | FileWritable, file},
+		{"~", true, FileExists, base},
+		{missing, false, FileNotExists | FileParentExists, missing},
+		{missing, false, FileWritable, missing},
+		{base, true, FileExecutable, base},
+	}
+	var failure = []struct {
+		input  string
+		dir    bool
+		checks FileCheck
+	}{
+		{missing, false, FileExists},
+		{file, true, FileExists},
+		{base, false, FileExists},
+		{file, false, FileNotExists},
+		{filepath.Join(missing, "rc"), false, FileParentExists},
+		{file, false, FileExecutable},
+		{missing, false, FileReadable},
+		{missing, true, FileExecutable},
+	}
+	var actual string
+
+	for _, test := range success {
+		actual = ""
+		unit := fileValue{&actual, test.dir, test.checks}
+
+		if err := unit.Set(test.input); nil != err {
+			t.Error("setting file value to", test.input, "yielded an error:", err.Error())
+		} else if actual != test.expected {
+			t.Error("file mismatch. expected:", test.expected, "got:", actual)
+		}
+	}
+
+	for _, test := range failure {
+		actual = ""
+		unit := fileValue{&actual, test.dir, test.checks}
+
+		if err := unit.Set(test.input); nil == err {
+			t.Error("invalid file input", test.input, "caused no error")
+		} else if len(actual) > 0 {
+			t.Error("invalid file input", test.input, "was stored")
+		}
+	}
+}
+
+func TestEnumValue(t *testing.T) {
+	actual := "fast"
+	unit := enumValue{&actual, []string{"fast", "safe"}}
+
+	if err := unit.Set("safe"); nil != err {
+		t.Error("setting enum value yielded an error:", err.Error())
+	} else if actual != "safe" {
+		t.Error("enum mismatch. expected: safe got:", actual)
+	}
+
+	if err := unit.Set("Fast"); nil == err {
+		t.Error("invalid enum input caused no error")
+	}
+}
+
+type repeatableTest struct {
+	split    string
+	inputs   []string
+	expected []string
+}
+
+func TestStringsValue(t *testing.T) {
+	var tests = []repeatableTest{
+		{"", []string{"a"}, []string{"a"}},
+		{"", []string{"a", "b"}, []string{"a", "b"}},
+		{"", []string{"a,b"}, []string{"a,b"}},
+		{",", []string{"a,b", "c"}, []string{"a", "b", "c"}},
+		{":", []string{"a,b:c"}, []string{"a,b", "c"}},
+	}
+
+	for _, test := range tests {
+		actual := []string{"default"}
+		unit := stringsValue{&actual, &test.split, nil, true}
+
+		if false == unit.IsRepeatable() {
+			t.Error("strings value is not identifying itself as repeatable")
+		}
+
+		for _, input := range test.inputs {
+			if err := unit.Set(input); nil != err {
+				t.Error("setting strings value to", input, "yielded an error:", err.Error())
+			}
+		}
+
+		if strings.Join(actual, "|") != strings.Join(test.expected, "|") {
+			t.Error("strings mismatch. expected:", test.expected, "got:", actual)
+		}
+	}
+}
+
+func TestIntsValue(t *testing.T) {
+	var failure = []string{"", "one", "1,x"}
+	var actual []int
+	split := ","
+	unit := intsValue{&actual, &split, nil, true}
+
+	for _, input := range []string{"1", "2,3"} {
+		if err := unit.Set(input); nil != err {
+			t.Error("setting ints value to", input, "yielded an error:", err.Error())
+		}
+	}
+
+	if unit.String() != "1,2,3" {
+		t.Error("ints mismatch. expected: 1,2,3 got:", unit.String())
+	}
+
+	for _, input := range failure {
+		if err := unit.Set(input); nil == err {
+			t.Error("invalid ints input", input, "caused no error")
+		} else if len(actual) != 3 {
+			t.Error("invalid ints input", input, "was stored")
+		}
+	}
+}
+
+func TestStringMapValue(t *testing.T) {
+	var success = []repeatableTest{
+		{"", []string{"a=1"}, []string{"a=1"}},
+		{"", []string{"a=1", "b=2", "a=3"}, []string{"a=3,b=2"}},
+		{"", []string{"a=1,b=2"}, []string{"a=1,b=2"}},
+		{",", []string{"a=1,b=2"}, []string{"a=1,b=2"}},
+		{"", []string{"url=a=b", "empty="}, []string{"empty=,url=a=b"}},
+	}
+	var failure = []string{"a", "=1", "a=1,b"}
+
+	for _, test := range success {
+		actual := map[string]string{"default": "x"}
+		unit := stringMapValue{actual, &test.split, nil, true}
+
+		for _, input := range test.inputs {
+			if err := unit.Set(input); nil != err {
+				t.Error("setting map value to", input, "yielded an error:", err.Error())
+			}
+		}
+
+		if unit.String() != test.expected[0] {
+			t.Error("map mismatch. expected:", test.expected[0], "got:", unit.String())
+		}
+	}
+
+	for _, input := range failure {
+		split := ","
+		actual := map[string]string{}
+		unit := stringMapValue{actual, &split, nil, false}
+
+		if err := unit.Set(input); nil == err {
+			t.Error("invalid map input", input, "caused no error")
+		} else if len(actual) > 0 {
+			t.Error("invalid map input", input, "was stored")
+		}
+	}
+}
+
+type valueTest struct {
+	input    string
+	expected string
+}
+
+type valueTable struct {
+	name    string
+	unit    func() Getter
+	success []valueTest
+	failure []string
+}
+
+func TestTypedValues(t *testing.T) {
+	var tables = []valueTable{
+		{"string", func() Getter { return &stringValue{new(string)} },
+			[]valueTest{{"", ""}, {"a b", "a b"}},
+			[]string{}},
+		{"float64", func() Getter { return &float64Value{new(float64)} },
+			[]valueTest{{"1.5", "1.5"}, {"-2", "-2"}, {"1e3", "1000"}},
+			[]string{"", "one", "1,5"}},
+		{"int64", func() Getter { return &int64Value{new(int64)} },
+			[]valueTest{{"-9223372036854775808", "-9223372036854775808"}, {"0x10", "16"}},
+			[]string{"", "1.5", "9223372036854775808"}},
+		{"uint64", func() Getter { return &uint64Value{new(uint64)} },
+			[]valueTest{{"18446744073709551615", "18446744073709551615"}, {"0b11", "3"}},
+			[]string{"", "-1", "18446744073709551616"}},
+		{"duration", func() Getter { return &durationValue{new(time.Duration)} },
+			[]valueTest{{"1m30s", "1m30s"}, {"0", "0s"}, {"-1.5h", "-1h30m0s"}},
+			[]string{"", "1", "1d"}},
+		{"time", func() Getter { return &timeValue{new(time.Time), time.DateOnly} },
+			[]valueTest{{"2026-10-18", "2026-10-18"}},
+			[]string{"", "18.10.2026", "2026-13-01"}},
+		{"byte size", func() Getter { return &byteSizeValue{new(uint64)} },
+			[]valueTest{{"10MiB", "10MiB"}, {"1024", "1KiB"}, {"1.5kb", "1500B"}, {"2 GB", "2GB"}, {"0", "0B"}},
+			[]string{"", "MiB", "-1KiB", "10XB", "20EiB", "100000PB", "1.5", "1.5B"}},
+		{"url", func() Getter { return &urlValue{new(url.URL)} },
+			[]valueTest{{"https://example.com/a?b=c", "https://example.com/a?b=c"}},
+			[]string{"", "example.com", "http://a b"}},
+		{"ip", func() Getter { return &ipValue{new(net.IP)} },
+			[]valueTest{{"10.0.0.1", "10.0.0.1"}, {"::1", "::1"}},
+			[]string{"", "10.0.0.256", "localhost"}},
+		{"ip net", func() Getter { return &ipNetValue{new(net.IPNet)} },
+			[]valueTest{{"10.1.2.3/8", "10.0.0.0/8"}, {"fd00::/64", "fd00::/64"}},
+			[]string{"", "10.0.0.1", "10.0.0.0/33"}},
+		{"regexp", func() Getter { return &regexpValue{new(*regexp.Regexp)} },
+			[]valueTest{{"^a+$", "^a+$"}, {"", ""}},
+			[]string{"(", "a**"}},
+		{"hex", func() Getter { return &hexValue{new([]byte)} },
+			[]valueTest{{"cafe01", "cafe01"}, {"CAFE", "cafe"}, {"", ""}},
+			[]string{"abc", "xyz"}},
+		{"base64", func() Getter { return &base64Value{new([]byte)} },
+			[]valueTest{{"aGk=", "aGk="}, {"aGk", "aGk="}, {"", ""}},
+			[]string{"a", "a-b_"}},
+	}
+
+	for _, table := range tables {
+		for _, test := range table.success {
+			unit := table.unit()
+
+			if err := unit.Set(test.input); nil != err {
+				t.Error("setting", table.name, "value to", test.input, "yielded an error:", err.Error())
+			} else if unit.String() != test.expected {
+				t.Error(table.name, "mismatch. expected:", test.expected, "got:", unit.String())
+			}
+		}
+
+		for _, input := range table.failure {
+			unit := table.unit()
+
+			if err := unit.Set(input); nil == err {
+				t.Error("invalid", table.name, "input", input, "caused no error")
+			} else if unit.String() != table.unit().String() {
+				t.Error("invalid", table.name, "input", input, "was stored:", unit.String())
+			}
+		}
+	}
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"command"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 )
 
 func main() {
-	var help bool = false
 	var jobs int = 1
 	var config *string
 	var version *bool
@@ -18,9 +18,6 @@ func main() {
 	app := command.NewParser(id, true)
 	test := app.Command("test", "Run the test suite")
 
-	app.Flag("help", "Show the usage message").
-		BoolVar(&help)
-
 	test.Flag("jobs", "Run NUM tests in parallel").
 		Value("NUM", false).
 		IntVar(&jobs)
@@ -37,17 +34,25 @@ func main() {
 		Value("FILE", true).
 		File("~/.config/app/rc")
 
-	if err := app.Parse(); nil != err {
-		app.PrintError(err.Error())
-	} else if help {
-		app.PrintUsage()
-	} else if *version {
-		printVersion(id, (*verbose) > 0)
-	} else if app.Triggered(test) {
-		runTests(*config, jobs, test.Args(), app.Args())
-	} else {
-		app.PrintError("App has no default behaviour")
-	}
+	test.Action(func(ctx *command.Context) error {
+		runTests(*config, jobs, ctx.Args(), app.Args())
+
+		return nil
+	})
+
+	app.Action(func(ctx *command.Context) error {
+		if *version {
+			printVersion(id, (*verbose) > 0)
+
+			return nil
+		}
+
+		return errors.New("App has no default behaviour")
+	})
+
+	app.ConfigFlag("config")
+	app.CancelOnSignal(true)
+	app.Main(os.Args[1:])
 }
 
 func printVersion(name string, verbose bool) {